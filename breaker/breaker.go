@@ -0,0 +1,229 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package breaker provides a circuit breaker that counts failures by error
+// code, so that technical errors trip it while business errors do not.
+package breaker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nextf/errors"
+)
+
+// ErrCircuitOpen is returned by a Breaker while it is open.
+const ErrCircuitOpen = errors.ConstError("[CB_TEC_CircuitOpen] Circuit breaker is open")
+
+// State is the state of a Breaker.
+type State int
+
+const (
+	// Closed breakers let all calls through.
+	Closed State = iota
+	// Open breakers reject all calls with ErrCircuitOpen.
+	Open
+	// HalfOpen breakers let a limited number of probe calls through,
+	// and close if they succeed or open again if one fails.
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// Config configures a Breaker. A zero field takes a default value.
+type Config struct {
+	// Keys select the errors counted as failures, each key is as in
+	// errors.Match, such as a regexp matching a family of codes, except an
+	// errors.PositionKey, on which New panics. Errors matching no key, like
+	// nil, are counted as successes.
+	Keys []interface{}
+	// Threshold is the number of consecutive failures with the same code
+	// that opens the breaker. The default is 5.
+	Threshold int
+	// Thresholds overrides Threshold for some codes.
+	Thresholds map[string]int
+	// OpenTimeout is how long the breaker stays open before probing.
+	// The default is 30 seconds.
+	OpenTimeout time.Duration
+	// Probes is the number of successful probes that close a half-open
+	// breaker, it is also the number of concurrent probes allowed.
+	// The default is 1.
+	Probes int
+	// Now returns the current time. The default is time.Now.
+	Now func() time.Time
+}
+
+// Breaker is a circuit breaker keyed on error codes.
+// It is safe for concurrent use.
+type Breaker struct {
+	cfg Config
+
+	mu       sync.Mutex
+	state    State
+	failures map[string]int
+	openedAt time.Time
+	probing  int
+	probed   int
+}
+
+// New returns a closed Breaker.
+func New(cfg Config) *Breaker {
+	for _, key := range cfg.Keys {
+		if _, ok := key.(errors.PositionKey); ok {
+			panic("breaker: a PositionKey cannot select the failures")
+		}
+	}
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = 5
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 30 * time.Second
+	}
+	if cfg.Probes <= 0 {
+		cfg.Probes = 1
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &Breaker{cfg: cfg, failures: make(map[string]int)}
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refresh()
+	return b.state
+}
+
+// refresh moves an open breaker to half-open once OpenTimeout has elapsed.
+func (b *Breaker) refresh() {
+	if b.state == Open && !b.cfg.Now().Before(b.openedAt.Add(b.cfg.OpenTimeout)) {
+		b.state = HalfOpen
+		b.probing = 0
+		b.probed = 0
+	}
+}
+
+// Allow reports whether a call may proceed. If so, the caller must report
+// the result of the call to done. Otherwise, Allow returns ErrCircuitOpen.
+func (b *Breaker) Allow() (done func(err error), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refresh()
+	switch b.state {
+	case Open:
+		return nil, ErrCircuitOpen
+	case HalfOpen:
+		if b.probing >= b.cfg.Probes-b.probed {
+			return nil, ErrCircuitOpen
+		}
+		b.probing++
+		return b.reporter(true), nil
+	}
+	return b.reporter(false), nil
+}
+
+func (b *Breaker) reporter(probe bool) func(err error) {
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() { b.record(err, probe) })
+	}
+}
+
+// Do calls fn if the breaker allows it, and records its result.
+func (b *Breaker) Do(fn func() error) error {
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+	err = fn()
+	done(err)
+	return err
+}
+
+func (b *Breaker) record(err error, probe bool) {
+	code, failed := b.failure(err)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		b.probing--
+		if b.state != HalfOpen {
+			return
+		}
+		if failed {
+			b.open()
+			return
+		}
+		if b.probed++; b.probed >= b.cfg.Probes {
+			b.state = Closed
+			b.failures = make(map[string]int)
+		}
+		return
+	}
+	if b.state != Closed {
+		return
+	}
+	if !failed {
+		if len(b.failures) > 0 {
+			b.failures = make(map[string]int)
+		}
+		return
+	}
+	b.failures[code]++
+	if b.failures[code] >= b.threshold(code) {
+		b.open()
+	}
+}
+
+func (b *Breaker) open() {
+	b.state = Open
+	b.openedAt = b.cfg.Now()
+	b.failures = make(map[string]int)
+}
+
+func (b *Breaker) threshold(code string) int {
+	if n, ok := b.cfg.Thresholds[code]; ok && n > 0 {
+		return n
+	}
+	return b.cfg.Threshold
+}
+
+// failure returns the code of the first error in err's chain matching one of
+// the keys, and reports whether there is one.
+func (b *Breaker) failure(err error) (string, bool) {
+	for link := range errors.All(err) {
+		x, ok := link.(interface {
+			Code() string
+			Match(interface{}) bool
+		})
+		if !ok || x == ErrCircuitOpen {
+			continue
+		}
+		for _, key := range b.cfg.Keys {
+			if x.Match(key) {
+				return x.Code(), true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,136 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package breaker_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/nextf/errors"
+	"github.com/nextf/errors/breaker"
+)
+
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time { return c.now }
+
+var (
+	errTimeout = errors.ErrCode("TEC_Timeout", "Timeout")
+	errRefused = errors.ErrCode("TEC_Refused", "Connection refused")
+	errOrder   = errors.ErrCode("BIS_Order", "Not found")
+)
+
+func newBreaker(c *clock) *breaker.Breaker {
+	return breaker.New(breaker.Config{
+		Keys:        []interface{}{regexp.MustCompile("^TEC_")},
+		Threshold:   3,
+		Thresholds:  map[string]int{"TEC_Refused": 1},
+		OpenTimeout: time.Minute,
+		Probes:      2,
+		Now:         c.Now,
+	})
+}
+
+func call(b *breaker.Breaker, err error) error {
+	return b.Do(func() error { return err })
+}
+
+func TestBreakerOpens(t *testing.T) {
+	c := &clock{time.Unix(0, 0)}
+	b := newBreaker(c)
+	for i := 0; i < 10; i++ {
+		call(b, errors.Wrap(errOrder, "SVC_Order", "Query failed"))
+	}
+	if b.State() != breaker.Closed {
+		t.Errorf("Expect %v, got %v", breaker.Closed, b.State())
+	}
+	call(b, errTimeout)
+	call(b, errTimeout)
+	call(b, nil)
+	call(b, errTimeout)
+	call(b, errTimeout)
+	if b.State() != breaker.Closed {
+		t.Errorf("Expect %v, got %v", breaker.Closed, b.State())
+	}
+	call(b, errors.Wrap(errTimeout, "SVC_Order", "Query failed"))
+	if b.State() != breaker.Open {
+		t.Errorf("Expect %v, got %v", breaker.Open, b.State())
+	}
+	called := false
+	err := b.Do(func() error {
+		called = true
+		return nil
+	})
+	if called || err != breaker.ErrCircuitOpen || !errors.Match(err, "CB_TEC_CircuitOpen") {
+		t.Errorf("Expect %v, got %v", breaker.ErrCircuitOpen, err)
+	}
+}
+
+func TestBreakerThresholds(t *testing.T) {
+	c := &clock{time.Unix(0, 0)}
+	b := newBreaker(c)
+	call(b, errRefused)
+	if b.State() != breaker.Open {
+		t.Errorf("Expect %v, got %v", breaker.Open, b.State())
+	}
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	c := &clock{time.Unix(0, 0)}
+	b := newBreaker(c)
+	call(b, errRefused)
+	c.now = c.now.Add(59 * time.Second)
+	if b.State() != breaker.Open {
+		t.Errorf("Expect %v, got %v", breaker.Open, b.State())
+	}
+	c.now = c.now.Add(time.Second)
+	if b.State() != breaker.HalfOpen {
+		t.Errorf("Expect %v, got %v", breaker.HalfOpen, b.State())
+	}
+
+	// Only two concurrent probes are allowed.
+	done1, err1 := b.Allow()
+	done2, err2 := b.Allow()
+	_, err3 := b.Allow()
+	if err1 != nil || err2 != nil || err3 != breaker.ErrCircuitOpen {
+		t.Errorf("Expect %v, got %v", breaker.ErrCircuitOpen, err3)
+	}
+	done1(nil)
+	done1(errRefused)
+	if b.State() != breaker.HalfOpen {
+		t.Errorf("Expect %v, got %v", breaker.HalfOpen, b.State())
+	}
+	done2(errOrder)
+	if b.State() != breaker.Closed {
+		t.Errorf("Expect %v, got %v", breaker.Closed, b.State())
+	}
+
+	call(b, errRefused)
+	c.now = c.now.Add(time.Minute)
+	call(b, errTimeout)
+	if b.State() != breaker.Open {
+		t.Errorf("Expect %v, got %v", breaker.Open, b.State())
+	}
+}
+
+func TestBreakerPositionKey(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expect New to panic on a PositionKey")
+		}
+	}()
+	breaker.New(breaker.Config{Keys: []interface{}{errors.Innermost("*_TEC_*")}})
+}
@@ -0,0 +1,193 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"sync/atomic"
+)
+
+// DefaultMaxChainDepth is the default maximum depth of the chains walked and
+// formatted by this package.
+const DefaultMaxChainDepth = 100
+
+var chainDepth atomic.Int64
+
+// SetMaxChainDepth sets the maximum number of errors deep walked in a chain by
+// Walk, Match, GetCode and the other functions of this package, and printed by
+// %+v, which marks the truncated chains with "... (chain truncated)".
+// If depth is not positive, DefaultMaxChainDepth is used.
+func SetMaxChainDepth(depth int) {
+	if depth <= 0 {
+		depth = DefaultMaxChainDepth
+	}
+	chainDepth.Store(int64(depth))
+}
+
+func maxChainDepth() int {
+	if depth := chainDepth.Load(); depth > 0 {
+		return int(depth)
+	}
+	return DefaultMaxChainDepth
+}
+
+// chainFormatter is implemented by the errors formatting their causes.
+type chainFormatter interface {
+	formatChain(s fmt.State, verb rune, ch chain)
+}
+
+// FormatOptions control the output of %+v.
+type FormatOptions struct {
+	// ChainDepth is the maximum number of errors of the chain printed, if
+	// positive. It is set by the precision of %+v, so that %+.3v prints the
+	// first 3 errors of the chain. Only the errors with a message count: the
+	// call stacks recorded by Wrap or Trace are printed with their error.
+	ChainDepth int
+	// StackDepth is the maximum number of frames printed for each call
+	// stack, if positive. It is set by the width of %+v.
+	StackDepth int
+	// InnermostStack prints only the last call stack of the chain, the one
+	// closest to the root cause. It is set by the flag # of %+#v.
+	InnermostStack bool
+}
+
+// Sprint formats err like %+v with the options.
+func (o FormatOptions) Sprint(err error) string {
+	format := "%+v"
+	if o.StackDepth > 0 {
+		format = fmt.Sprintf("%%+%dv", o.StackDepth)
+	}
+	if x, ok := formattable(err).(chainFormatter); ok {
+		return fmt.Sprintf(format, &chainLink{x, newChainOf(err, o)})
+	}
+	return fmt.Sprintf(format, err)
+}
+
+// chain is the path from the outermost error being formatted to the error
+// formatting its causes.
+type chain struct {
+	path []error
+	// messages is the number of errors of path printing a message.
+	messages int
+	opts     FormatOptions
+}
+
+// newChain returns the chain of err formatted as the outermost error by s.
+func newChain(s fmt.State, err error) chain {
+	var opts FormatOptions
+	if s.Flag('+') {
+		opts.ChainDepth, _ = s.Precision()
+		opts.StackDepth, _ = s.Width()
+		opts.InnermostStack = s.Flag('#')
+	}
+	return newChainOf(err, opts)
+}
+
+// newChainOf returns the chain of err formatted as the outermost error with
+// opts.
+func newChainOf(err error, opts FormatOptions) chain {
+	ch := chain{path: []error{err}, opts: opts}
+	if printsMessage(err) {
+		ch.messages = 1
+	}
+	return ch
+}
+
+// link returns the value to format in place of cause, the cause of the last
+// error of ch, or a marker if the chain is too deep or cause is already in ch.
+func (ch chain) link(cause error) interface{} {
+	if len(ch.path) >= maxChainDepth() || ch.contains(cause) {
+		return truncated{}
+	}
+	messages := ch.messages
+	if printsMessage(cause) {
+		if ch.opts.ChainDepth > 0 && messages >= ch.opts.ChainDepth {
+			return truncated{}
+		}
+		messages++
+	}
+	x, ok := formattable(cause).(chainFormatter)
+	if !ok {
+		return cause
+	}
+	return &chainLink{x, chain{append(ch.path[:len(ch.path):len(ch.path)], cause), messages, ch.opts}}
+}
+
+// printsMessage reports whether %+v prints a message for err, rather than
+// only its call stack or its cause.
+func printsMessage(err error) bool {
+	switch err.(type) {
+	case *errorStack, *withClass, *withSuppressed:
+		return false
+	}
+	return true
+}
+
+// skipStack reports whether the call stack of an error whose cause is cause
+// is not printed, since only the innermost one is.
+func (ch chain) skipStack(cause error) bool {
+	return ch.opts.InnermostStack && HasStackTrace(cause)
+}
+
+// causeFormat returns the format of the cause of an error formatted with
+// verb. Only the flags + and - and the width, the depth of call stacks, are
+// kept: the other flags and the precision would change how errors of other
+// packages print themselves.
+func causeFormat(s fmt.State, verb rune) string {
+	if verb != 'v' {
+		return "%" + string(verb)
+	}
+	format := "%"
+	if s.Flag('+') {
+		format += "+"
+	}
+	if s.Flag('-') {
+		format += "-"
+	}
+	if width, ok := s.Width(); ok {
+		format += strconv.Itoa(width)
+	}
+	return format + "v"
+}
+
+func (ch chain) contains(err error) bool {
+	if !identifiable(err) {
+		return false
+	}
+	for _, link := range ch.path {
+		if link == err {
+			return true
+		}
+	}
+	return false
+}
+
+// chainLink formats an error at its position in the chain.
+type chainLink struct {
+	err chainFormatter
+	ch  chain
+}
+
+func (l *chainLink) Format(s fmt.State, verb rune) {
+	l.err.formatChain(s, verb, l.ch)
+}
+
+type truncated struct{}
+
+func (truncated) Format(s fmt.State, verb rune) {
+	io.WriteString(s, "... (chain truncated)")
+}
@@ -0,0 +1,233 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors_test
+
+import (
+	stderr "errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nextf/errors"
+)
+
+const tab = "\x20\x20\x20\x20"
+
+// copyError unwraps to a copy of itself. It is not comparable, so its chain
+// is only bounded by the maximum depth.
+type copyError struct {
+	depth []int
+}
+
+func (e copyError) Error() string { return fmt.Sprintf("copy %d", len(e.depth)) }
+func (e copyError) Unwrap() error { return copyError{append(e.depth, 0)} }
+
+// causeLoop is a pkg/errors style error with a call stack that is its own
+// cause.
+type causeLoop struct{}
+
+func (e *causeLoop) Error() string         { return "loop" }
+func (e *causeLoop) Cause() error          { return e }
+func (e *causeLoop) StackTrace() []uintptr { return nil }
+
+func TestMaxChainDepth(t *testing.T) {
+	defer errors.SetMaxChainDepth(0)
+	var err error = errors.ErrCode("ROOT", "Root")
+	for i := 0; i < 10; i++ {
+		err = errors.WithErrCode(err, fmt.Sprintf("L%d", i), "Level")
+	}
+
+	errors.SetMaxChainDepth(3)
+	var depths []int
+	errors.Walk(err, func(link error, depth int) bool {
+		depths = append(depths, depth)
+		return true
+	})
+	if len(depths) != 3 || depths[2] != 2 {
+		t.Errorf("Expect depths [0 1 2], got %v", depths)
+	}
+	if errors.Match(err, "ROOT") || !errors.Match(err, "L7") {
+		t.Errorf("Expect only the first 3 links to match")
+	}
+	if code, _ := errors.RootCode(err); code != "L7" {
+		t.Errorf("Expect code=%s, got %s", "L7", code)
+	}
+	want := "[L9] Level\nCaused by: [L8] Level\nCaused by: [L7] Level\nCaused by: ... (chain truncated)"
+	if s := fmt.Sprintf("%+v", err); s != want {
+		t.Errorf("Expect %q, got %q", want, s)
+	}
+
+	errors.SetMaxChainDepth(-1)
+	if !errors.Match(err, "ROOT") {
+		t.Errorf("Expect the default depth to be restored")
+	}
+}
+
+func TestAdversarialChains(t *testing.T) {
+	self := &loopError{code: "SELF"}
+	self.next = self
+	tests := []struct {
+		err    error
+		suffix string
+	}{
+		{errors.WithErrCode(self, "TOP", "Top"), "[TOP] Top\nCaused by: SELF"},
+		{errors.Wrap(&causeLoop{}, "TOP", "Top"), "\nCaused by: ... (chain truncated)"},
+		{errors.Annotate(copyError{}, "Annotated"), "Annotated\nCaused by: copy 0"},
+		{errors.AddSuppressed(errors.ErrCode("P", "Primary"), self), "[P] Primary\nSuppressed: SELF"},
+	}
+	for _, tt := range tests {
+		if errors.Match(tt.err, "NONE") || errors.HasStackTrace(tt.err) != strings.Contains(tt.suffix, "truncated") {
+			t.Errorf("%v: unexpected Match or HasStackTrace", tt.err)
+		}
+		errors.Cause(tt.err)
+		errors.CodePath(tt.err)
+		errors.Fingerprint(tt.err)
+		errors.Suppressed(tt.err)
+		errors.ClassOf(tt.err)
+		if s := fmt.Sprintf("%+v", tt.err); !strings.HasSuffix(s, tt.suffix) {
+			t.Errorf("Expect suffix %q, got %q", tt.suffix, s)
+		}
+	}
+
+	n := 0
+	errors.Walk(copyError{}, func(error, int) bool {
+		n++
+		return true
+	})
+	if n != errors.DefaultMaxChainDepth {
+		t.Errorf("Expect %d links, got %d", errors.DefaultMaxChainDepth, n)
+	}
+	if cause := errors.Cause(copyError{}); cause.Error() != fmt.Sprintf("copy %d", errors.DefaultMaxChainDepth-1) {
+		t.Errorf("Expect the deepest link walked, got %v", cause)
+	}
+}
+
+// causeTo is a pkg/errors style error with a cause set after it is created.
+type causeTo struct {
+	cause error
+}
+
+func (e *causeTo) Error() string { return "cause to" }
+func (e *causeTo) Cause() error  { return e.cause }
+
+func TestSuppressedCycle(t *testing.T) {
+	secondary := &causeTo{}
+	err := errors.AddSuppressed(errors.ErrCode("P", "Primary"), secondary)
+	secondary.cause = err
+	want := "[P] Primary\nSuppressed: cause to\n    Caused by: ... (chain truncated)"
+	if s := fmt.Sprintf("%+v", err); s != want {
+		t.Errorf("Expect %q, got %q", want, s)
+	}
+	if n := len(errors.Suppressed(err)); n != 1 {
+		t.Errorf("Expect %d suppressed error, got %d", 1, n)
+	}
+}
+
+func TestHugeChain(t *testing.T) {
+	var err error = errors.ErrCode("ROOT", "Root")
+	for i := 0; i < 100000; i++ {
+		err = errors.WithErrCode(err, "L", "Level")
+	}
+	if errors.Match(err, "ROOT") {
+		t.Errorf("Expect the root beyond the maximum depth not to match")
+	}
+	s := fmt.Sprintf("%+v", err)
+	if n := strings.Count(s, "Caused by: "); n != errors.DefaultMaxChainDepth {
+		t.Errorf("Expect %d causes, got %d", errors.DefaultMaxChainDepth, n)
+	}
+	if !strings.HasSuffix(s, "... (chain truncated)") {
+		t.Errorf("Expect the chain to be truncated")
+	}
+}
+
+func TestFormatChainDepth(t *testing.T) {
+	err := errors.WithErrCode(errors.WithErrCode(errors.ErrCode("C", "c"), "B", "b"), "A", "a")
+	tests := []struct {
+		format, want string
+	}{
+		{"%+.1v", "[A] a\nCaused by: ... (chain truncated)"},
+		{"%+.2v", "[A] a\nCaused by: [B] b\nCaused by: ... (chain truncated)"},
+		{"%+.3v", "[A] a\nCaused by: [B] b\nCaused by: [C] c"},
+		{"%+.0v", "[A] a\nCaused by: [B] b\nCaused by: [C] c"},
+		{"%.1v", "[A] a"},
+	}
+	for _, tt := range tests {
+		if s := fmt.Sprintf(tt.format, err); s != tt.want {
+			t.Errorf("%s: Expect %q, got %q", tt.format, tt.want, s)
+		}
+	}
+	if s := (errors.FormatOptions{ChainDepth: 2}).Sprint(err); s != tests[1].want {
+		t.Errorf("Expect %q, got %q", tests[1].want, s)
+	}
+}
+
+func TestFormatInnermostStack(t *testing.T) {
+	err := errors.Wrap(errors.Wrap(errors.TraceableErrCode("C", "c"), "B", "b"), "A", "a")
+	for _, s := range []string{fmt.Sprintf("%+#v", err), (errors.FormatOptions{InnermostStack: true}).Sprint(err)} {
+		if n := strings.Count(s, "@callstack"); n != 1 {
+			t.Errorf("Expect %d call stack, got %d:\n%s", 1, n, s)
+		}
+		want := "[A] a\nCaused by: [B] b\nCaused by: [C] c\nCaused by: @callstack\n" + tab + "github.com/nextf/errors_test.TestFormatInnermostStack"
+		if !strings.HasPrefix(s, want) {
+			t.Errorf("Expect prefix %q, got %q", want, s)
+		}
+	}
+	if n := strings.Count(fmt.Sprintf("%+v", err), "@callstack"); n != 3 {
+		t.Errorf("Expect %d call stacks, got %d", 3, n)
+	}
+
+	s := fmt.Sprintf("%+#1v", err)
+	if n := strings.Count(s, "\n"+tab); n != 2 {
+		t.Errorf("Expect 1 frame and a line of more frames, got:\n%s", s)
+	}
+	if o := (errors.FormatOptions{StackDepth: 1, InnermostStack: true}); o.Sprint(err) != s {
+		t.Errorf("Expect %q, got %q", s, o.Sprint(err))
+	}
+	want := "[A] a\nCaused by: [B] b\nCaused by: ... (chain truncated)"
+	if s = fmt.Sprintf("%+#.2v", err); s != want {
+		t.Errorf("Expect %q, got %q", want, s)
+	}
+}
+
+func TestFormatChainDepthWithStacks(t *testing.T) {
+	err := errors.Wrap(errors.Wrap(errors.ErrCode("C", "c"), "B", "b"), "A", "a")
+	s := fmt.Sprintf("%+1.2v", err)
+	if n := strings.Count(s, "@callstack"); n != 2 {
+		t.Errorf("Expect %d call stacks, got %d:\n%s", 2, n, s)
+	}
+	if !strings.Contains(s, "\nCaused by: [B] b\n") || !strings.HasSuffix(s, "\nCaused by: ... (chain truncated)") {
+		t.Errorf("Expect the errors A and B with their call stacks, got:\n%s", s)
+	}
+}
+
+func TestFormatForeignCause(t *testing.T) {
+	traced := errors.Trace(fmt.Errorf("ctx: %w", errors.Trace(stderr.New("base"))))
+	for _, c := range []struct {
+		format string
+		err    error
+		want   string
+	}{
+		{"%+#v", errors.WithRetryAfter(io.EOF, time.Second), "EOF"},
+		{"%+.1v", errors.WithClass(io.ErrUnexpectedEOF, errors.Retryable), "unexpected EOF"},
+		{"%+.2v", errors.AddSuppressed(stderr.New("disk full of stuff"), stderr.New("device busy")), "disk full of stuff\nSuppressed: device busy"},
+		{"%+#.3v", traced, traced.(interface{ Unwrap() error }).Unwrap().Error()},
+	} {
+		if text := fmt.Sprintf(c.format, c.err); text != c.want {
+			t.Errorf("%s: Expect %q, got %q", c.format, c.want, text)
+		}
+	}
+}
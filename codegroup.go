@@ -0,0 +1,73 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+// CodeGroup is a closed set of error codes, such as the codes a service
+// may return. Declare it as a package-level variable with constant codes
+//
+//	var OrderCodes = errors.NewCodeGroup("NF_BIS_Order", "AD_TEC_DbConnect")
+//
+// so that the codeswitch analyzer can report switches and Match cascades
+// that do not handle every code of the group.
+//
+// A CodeGroup can be used as a key of Match.
+type CodeGroup struct {
+	codes []string
+	index map[string]struct{}
+}
+
+// NewCodeGroup returns a group of the codes.
+func NewCodeGroup(codes ...string) *CodeGroup {
+	g := &CodeGroup{index: make(map[string]struct{}, len(codes))}
+	for _, code := range codes {
+		if _, ok := g.index[code]; ok {
+			continue
+		}
+		g.index[code] = struct{}{}
+		g.codes = append(g.codes, code)
+	}
+	return g
+}
+
+// Codes returns the codes of the group in declaration order.
+func (g *CodeGroup) Codes() []string {
+	return append([]string(nil), g.codes...)
+}
+
+// Contains reports whether code belongs to the group.
+func (g *CodeGroup) Contains(code string) bool {
+	_, ok := g.index[code]
+	return ok
+}
+
+// MatchString reports whether code belongs to the group.
+// It makes a CodeGroup usable as a key of Match.
+func (g *CodeGroup) MatchString(code string) bool {
+	return g.Contains(code)
+}
+
+// Of finds the first error in err's chain whose code belongs to the group,
+// and if so, returns the code and the boolean is true.
+// Otherwise the returned value will be empty and the boolean will be false.
+func (g *CodeGroup) Of(err error) (string, bool) {
+	x := Find(err, func(link error) bool {
+		x, ok := link.(interface{ Code() string })
+		return ok && g.Contains(x.Code())
+	})
+	if x == nil {
+		return "", false
+	}
+	return x.(interface{ Code() string }).Code(), true
+}
@@ -0,0 +1,50 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nextf/errors"
+)
+
+var orderCodes = errors.NewCodeGroup("NF_BIS_Order", "AD_TEC_DbConnect", "NF_BIS_Order")
+
+func TestCodeGroup(t *testing.T) {
+	if codes := orderCodes.Codes(); !reflect.DeepEqual(codes, []string{"NF_BIS_Order", "AD_TEC_DbConnect"}) {
+		t.Errorf("Expect %v, got %v", []string{"NF_BIS_Order", "AD_TEC_DbConnect"}, codes)
+	}
+	if !orderCodes.Contains("AD_TEC_DbConnect") {
+		t.Errorf("Expect %v, got %v", true, false)
+	}
+	if orderCodes.Contains("AD_TEC") {
+		t.Errorf("Expect %v, got %v", false, true)
+	}
+
+	err := errors.WithErrCode(errors.ErrCode("AD_TEC_DbConnect", "Access denied"), "SVC_Order", "Query failed")
+	if !errors.Match(err, orderCodes) {
+		t.Errorf("Expect %v, got %v", "[Match]", "[NotMatch]")
+	}
+	if code, ok := orderCodes.Of(err); !ok || code != "AD_TEC_DbConnect" {
+		t.Errorf("Expect code=%s, got [%s]", "AD_TEC_DbConnect", code)
+	}
+	if code, ok := orderCodes.Of(errors.ErrCode("SVC_Order", "Query failed")); ok {
+		t.Errorf("Expect no code, got [%s]", code)
+	}
+	if _, ok := orderCodes.Of(nil); ok {
+		t.Errorf("Expect no code, got one")
+	}
+}
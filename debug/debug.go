@@ -0,0 +1,255 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package debug retains the most recent errors in memory and serves them
+// over HTTP, for on-call debugging.
+//
+// Record the errors where they are handled:
+//
+//	if err != nil {
+//		debug.Record(err, "path", r.URL.Path)
+//	}
+//
+// and mount the handler on an admin port:
+//
+//	mux.Handle("/debug/errors", debug.Handler(debug.Default))
+//
+// The handler renders HTML, or JSON with the query parameter format=json.
+// The query parameter code restricts the errors to those matching a code.
+package debug
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/nextf/errors"
+	"github.com/nextf/errors/stack"
+)
+
+// Entry is an error retained by a Buffer.
+type Entry struct {
+	Seq         uint64            `json:"seq"`
+	Time        time.Time         `json:"time"`
+	Code        string            `json:"code,omitempty"`
+	Message     string            `json:"message"`
+	Fields      map[string]string `json:"fields,omitempty"`
+	Frames      []string          `json:"frames,omitempty"`
+	Fingerprint string            `json:"fingerprint"`
+}
+
+// Group summarizes the retained errors with the same fingerprint.
+type Group struct {
+	Fingerprint string    `json:"fingerprint"`
+	Code        string    `json:"code,omitempty"`
+	Count       int       `json:"count"`
+	First       time.Time `json:"first"`
+	Last        time.Time `json:"last"`
+	Sample      *Entry    `json:"sample"`
+}
+
+// Buffer retains the last errors recorded. It is safe for concurrent use,
+// recording only contends on an atomic counter.
+type Buffer struct {
+	frames int
+	next   atomic.Uint64
+	slots  []atomic.Pointer[Entry]
+	now    func() time.Time
+}
+
+// NewBuffer returns a buffer retaining the last size errors with up to
+// frames frames of their call stack.
+func NewBuffer(size, frames int) *Buffer {
+	if size <= 0 {
+		size = 1
+	}
+	return &Buffer{frames: frames, slots: make([]atomic.Pointer[Entry], size), now: time.Now}
+}
+
+// Default is the buffer used by Record, retaining the last 256 errors.
+var Default = NewBuffer(256, 8)
+
+// Record records err in the Default buffer with fields given as key-value
+// pairs.
+func Record(err error, keyvals ...string) {
+	Default.Record(err, keyvals...)
+}
+
+// Record records err with fields given as key-value pairs.
+// If err is nil, Record does nothing.
+func (b *Buffer) Record(err error, keyvals ...string) {
+	if err == nil {
+		return
+	}
+	code, _ := errors.GetCode(err)
+	e := &Entry{
+		Time:        b.now(),
+		Code:        code,
+		Message:     err.Error(),
+		Frames:      describe(innermostStack(err), b.frames),
+		Fingerprint: errors.Fingerprint(err),
+	}
+	if len(keyvals) > 0 {
+		e.Fields = make(map[string]string, (len(keyvals)+1)/2)
+		for i := 0; i < len(keyvals); i += 2 {
+			if i+1 < len(keyvals) {
+				e.Fields[keyvals[i]] = keyvals[i+1]
+			} else {
+				e.Fields[keyvals[i]] = ""
+			}
+		}
+	}
+	seq := b.next.Add(1)
+	e.Seq = seq
+	slot := &b.slots[(seq-1)%uint64(len(b.slots))]
+	for {
+		// A delayed recording must not replace a later error in the slot.
+		old := slot.Load()
+		if old != nil && old.Seq > seq || slot.CompareAndSwap(old, e) {
+			return
+		}
+	}
+}
+
+// Hook returns a hook recording every error built by the constructors of
+// github.com/nextf/errors, to be installed with errors.AddHook. As each
+// layer of wrapping is recorded, it is mostly useful with a filter.
+func (b *Buffer) Hook(filter func(errors.Event) bool) errors.Hook {
+	return func(e errors.Event) {
+		if filter == nil || filter(e) {
+			b.Record(e.Err)
+		}
+	}
+}
+
+// Entries returns the retained errors, the most recent first.
+func (b *Buffer) Entries() []*Entry {
+	entries := make([]*Entry, 0, len(b.slots))
+	for i := range b.slots {
+		if e := b.slots[i].Load(); e != nil {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Seq > entries[j].Seq })
+	return entries
+}
+
+// Groups groups entries by their errors.Fingerprint, the most frequent first.
+func Groups(entries []*Entry) []*Group {
+	index := make(map[string]*Group)
+	var groups []*Group
+	for _, e := range entries {
+		g, ok := index[e.Fingerprint]
+		if !ok {
+			g = &Group{Fingerprint: e.Fingerprint, Code: e.Code, First: e.Time, Last: e.Time, Sample: e}
+			index[e.Fingerprint] = g
+			groups = append(groups, g)
+		}
+		g.Count++
+		if e.Time.Before(g.First) {
+			g.First = e.Time
+		}
+		if e.Time.After(g.Last) {
+			g.Last = e.Time
+			g.Sample = e
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Count > groups[j].Count })
+	return groups
+}
+
+// innermostStack returns the call stack of the last error in err's chain
+// that has one.
+func innermostStack(err error) []stack.Frame {
+	var frames []stack.Frame
+	for link := range errors.All(err) {
+		if x, ok := errors.StackTrace(link); ok {
+			frames = x
+		}
+	}
+	return frames
+}
+
+func describe(frames []stack.Frame, n int) []string {
+	if n >= 0 && len(frames) > n {
+		frames = frames[:n]
+	}
+	descriptions := make([]string, len(frames))
+	for i, frame := range frames {
+		descriptions[i] = frame.Describe()
+	}
+	return descriptions
+}
+
+// Handler returns a handler serving the errors retained by b.
+func Handler(b *Buffer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entries := b.Entries()
+		if code := r.URL.Query().Get("code"); code != "" {
+			filtered := entries[:0]
+			for _, e := range entries {
+				if e.Code == code {
+					filtered = append(filtered, e)
+				}
+			}
+			entries = filtered
+		}
+		page := struct {
+			Groups  []*Group `json:"groups"`
+			Entries []*Entry `json:"entries"`
+		}{Groups(entries), entries}
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		if r.URL.Query().Get("format") == "json" {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			enc.Encode(page)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		pageTemplate.Execute(w, page)
+	})
+}
+
+var pageTemplate = template.Must(template.New("errors").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>Recent errors</title>
+<style>
+body { font-family: sans-serif; font-size: 14px; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 2px 6px; text-align: left; vertical-align: top; }
+pre { margin: 0; }
+</style>
+</head>
+<body>
+<h1>Recent errors</h1>
+<p><a href="?format=json">json</a></p>
+<h2>Groups</h2>
+<table>
+<tr><th>Count</th><th>Code</th><th>Message</th><th>First</th><th>Last</th><th>Fingerprint</th></tr>
+{{range .Groups}}<tr><td>{{.Count}}</td><td><a href="?code={{.Code}}">{{.Code}}</a></td><td>{{.Sample.Message}}</td><td>{{.First.Format "2006-01-02 15:04:05.000"}}</td><td>{{.Last.Format "2006-01-02 15:04:05.000"}}</td><td>{{.Fingerprint}}</td></tr>
+{{end}}</table>
+<h2>Errors</h2>
+<table>
+<tr><th>#</th><th>Time</th><th>Code</th><th>Message</th><th>Fields</th><th>Frames</th></tr>
+{{range .Entries}}<tr><td>{{.Seq}}</td><td>{{.Time.Format "2006-01-02 15:04:05.000"}}</td><td>{{.Code}}</td><td>{{.Message}}</td><td>{{range $k, $v := .Fields}}{{$k}}={{$v}}<br>{{end}}</td><td><pre>{{range .Frames}}{{.}}
+{{end}}</pre></td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
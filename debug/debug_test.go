@@ -0,0 +1,136 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nextf/errors"
+	"github.com/nextf/errors/debug"
+)
+
+func findOrder(id int) error {
+	return errors.TraceableErrCodef("NF_BIS_Order", "Not found order %d", id)
+}
+
+func TestBuffer(t *testing.T) {
+	b := debug.NewBuffer(3, 2)
+	b.Record(nil)
+	for i := 0; i < 4; i++ {
+		b.Record(findOrder(i), "order", "o1", "odd")
+	}
+	b.Record(errors.ErrCode("AD_TEC_Db", "Access denied"))
+
+	entries := b.Entries()
+	if len(entries) != 3 || entries[0].Seq != 5 || entries[2].Seq != 3 {
+		t.Fatalf("Unexpected entries %+v", entries)
+	}
+	e := entries[1]
+	if e.Code != "NF_BIS_Order" || e.Message != "Not found order 3" || e.Fields["order"] != "o1" || e.Fields["odd"] != "" {
+		t.Errorf("Unexpected entry %+v", e)
+	}
+	if len(e.Frames) != 2 || !strings.HasPrefix(e.Frames[0], "github.com/nextf/errors/debug_test.findOrder(debug_test.go:") {
+		t.Errorf("Unexpected frames %v", e.Frames)
+	}
+
+	groups := debug.Groups(entries)
+	if len(groups) != 2 || groups[0].Count != 2 || groups[0].Code != "NF_BIS_Order" || groups[0].Sample != entries[1] {
+		t.Errorf("Unexpected groups %+v", groups)
+	}
+	if entries[1].Fingerprint != entries[2].Fingerprint || entries[0].Fingerprint == entries[1].Fingerprint {
+		t.Errorf("Expect the same fingerprint for the same failure")
+	}
+}
+
+// pkgError is an error with a call stack recorded like github.com/pkg/errors.
+type pkgError struct{ stack []uintptr }
+
+func (e *pkgError) Error() string { return "pkg error" }
+
+func (e *pkgError) StackTrace() []uintptr { return e.stack }
+
+func TestBufferPkgErrors(t *testing.T) {
+	pcs := make([]uintptr, 32)
+	err := errors.Wrap(&pkgError{pcs[:runtime.Callers(1, pcs)]}, "TEC_Pkg", "Pkg failed")
+	b := debug.NewBuffer(1, 1)
+	b.Record(err)
+	if e := b.Entries()[0]; len(e.Frames) != 1 || !strings.HasPrefix(e.Frames[0], "github.com/nextf/errors/debug_test.TestBufferPkgErrors(debug_test.go:") {
+		t.Errorf("Unexpected frames %v", e.Frames)
+	}
+}
+
+func TestBufferConcurrent(t *testing.T) {
+	b := debug.NewBuffer(16, 0)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				b.Record(errors.ErrCode("TEC_Timeout", "Timeout"))
+				b.Entries()
+			}
+		}()
+	}
+	wg.Wait()
+	if entries := b.Entries(); len(entries) != 16 || entries[0].Seq != 800 {
+		t.Errorf("Unexpected entries %d", len(entries))
+	}
+}
+
+func TestHook(t *testing.T) {
+	b := debug.NewBuffer(8, 0)
+	remove := errors.AddHook(b.Hook(func(e errors.Event) bool { return e.Cause == nil }))
+	errors.Wrap(errors.ErrCode("ROOT", "Level 0"), "L1", "Level 1")
+	remove()
+	if entries := b.Entries(); len(entries) != 1 || entries[0].Code != "ROOT" {
+		t.Errorf("Unexpected entries %+v", entries)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	b := debug.NewBuffer(8, 4)
+	b.Record(findOrder(1), "path", "/orders/<1>")
+	b.Record(errors.ErrCode("AD_TEC_Db", "Access denied"))
+	h := debug.Handler(b)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/debug/errors?format=json&code=NF_BIS_Order", nil))
+	var page struct {
+		Groups  []debug.Group
+		Entries []debug.Entry
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Entries) != 1 || len(page.Groups) != 1 || page.Entries[0].Code != "NF_BIS_Order" {
+		t.Errorf("Unexpected page %+v", page)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/debug/errors", nil))
+	body := w.Body.String()
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+		t.Errorf("Unexpected content type %s", w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(body, "Not found order 1") || !strings.Contains(body, "AD_TEC_Db") || !strings.Contains(body, "path=/orders/&lt;1&gt;") {
+		t.Errorf("Unexpected page\n%s", body)
+	}
+}
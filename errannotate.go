@@ -0,0 +1,61 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"fmt"
+	"io"
+)
+
+// withAnnotation adds a message to its cause and inherits its code.
+type withAnnotation struct {
+	message string
+	cause   error
+}
+
+func (c *withAnnotation) Error() string {
+	return c.message
+}
+
+// Unwrap provides compatibility for Go 1.13 error chains.
+func (c *withAnnotation) Unwrap() error {
+	return c.cause
+}
+
+func (c *withAnnotation) Format(s fmt.State, verb rune) {
+	format(s, verb, c, c)
+}
+
+func (c *withAnnotation) formatChain(s fmt.State, verb rune, ch chain) {
+	switch verb {
+	case 'v':
+		if code, ok := GetCode(c.cause); ok && code != "" {
+			fmt.Fprintf(s, "[%s] %s", code, c.message)
+		} else {
+			io.WriteString(s, c.message)
+		}
+		if s.Flag('+') && c.cause != nil {
+			formatCause := "\nCaused by: %+v"
+			if width, ok := s.Width(); ok {
+				formatCause = fmt.Sprintf("\nCaused by: %%+%dv", width)
+			}
+			fmt.Fprintf(s, formatCause, ch.link(c.cause))
+		}
+	case 's':
+		io.WriteString(s, c.message)
+	case 'q':
+		fmt.Fprintf(s, "%q", c.message)
+	}
+}
@@ -34,16 +34,12 @@ func (c *withErrCode) Code() string {
 }
 
 func (c *withErrCode) Match(key interface{}) bool {
-	if key == nil {
-		return false
-	}
-	switch x := key.(type) {
-	case string:
-		return c.code == x
-	case interface{ MatchString(s string) bool }:
-		return x.MatchString(c.code)
-	}
-	return false
+	return matchCode(c.code, key)
+}
+
+// Is reports whether target is a CodeTarget of the same code.
+func (c *withErrCode) Is(target error) bool {
+	return sameCode(c.code, target)
 }
 
 // Unwrap provides compatibility for Go 1.13 error chains.
@@ -52,6 +48,10 @@ func (c *withErrCode) Unwrap() error {
 }
 
 func (c *withErrCode) Format(s fmt.State, verb rune) {
+	format(s, verb, c, c)
+}
+
+func (c *withErrCode) formatChain(s fmt.State, verb rune, ch chain) {
 	switch verb {
 	case 'v':
 		fmt.Fprintf(s, "[%s] %s", c.code, c.message)
@@ -60,7 +60,7 @@ func (c *withErrCode) Format(s fmt.State, verb rune) {
 			if width, ok := s.Width(); ok {
 				formatCause = fmt.Sprintf("\nCaused by: %%+%dv", width)
 			}
-			fmt.Fprintf(s, formatCause, c.cause)
+			fmt.Fprintf(s, formatCause, ch.link(c.cause))
 		}
 	case 's':
 		io.WriteString(s, c.message)
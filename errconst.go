@@ -37,15 +37,11 @@ func (e ConstError) Code() string {
 	return ""
 }
 
+// Is reports whether target is a CodeTarget of the same code.
+func (e ConstError) Is(target error) bool {
+	return sameCode(e.Code(), target)
+}
+
 func (e ConstError) Match(key interface{}) bool {
-	if key == nil {
-		return false
-	}
-	switch x := key.(type) {
-	case string:
-		return e.Code() == x
-	case interface{ MatchString(s string) bool }:
-		return x.MatchString(e.Code())
-	}
-	return false
+	return matchCode(e.Code(), key)
 }
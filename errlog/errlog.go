@@ -0,0 +1,170 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package errlog logs errors, suppressing repeated identical errors.
+//
+// The first occurrence of a failure, identified by errors.Fingerprint, is
+// logged with its %+v trace. The following occurrences within the window are
+// only counted, and summarized when the window ends:
+//
+//	NF_BIS_Order occurred 1,532 times in the last 60s, sample:
+//	[NF_BIS_Order] Not found orders
+//	...
+package errlog
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nextf/errors"
+)
+
+// Printer is the output of a Logger. A *log.Logger is a Printer.
+type Printer interface {
+	Printf(format string, v ...interface{})
+}
+
+type occurrence struct {
+	fingerprint string
+	name        string
+	start       time.Time
+	count       int
+	sample      error
+}
+
+// Logger is a deduplicating error logger. It is safe for concurrent use.
+type Logger struct {
+	out    Printer
+	window time.Duration
+	now    func() time.Time
+
+	mu          sync.Mutex
+	occurrences map[string]*occurrence
+	// windows holds the occurrences in the order their windows started,
+	// which is the order they end.
+	windows []*occurrence
+}
+
+// New returns a logger printing to out, that suppresses the repeated errors
+// within window.
+func New(out Printer, window time.Duration) *Logger {
+	return NewWithClock(out, window, time.Now)
+}
+
+// NewWithClock is like New with a function returning the current time.
+func NewWithClock(out Printer, window time.Duration, now func() time.Time) *Logger {
+	return &Logger{out: out, window: window, now: now, occurrences: make(map[string]*occurrence)}
+}
+
+// Log logs err, unless an identical error was logged within the window.
+// If err is nil, Log does nothing.
+func (l *Logger) Log(err error) {
+	if err == nil {
+		return
+	}
+	fingerprint := errors.Fingerprint(err)
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.flush(now)
+	if o, ok := l.occurrences[fingerprint]; ok {
+		o.count++
+		o.sample = err
+		return
+	}
+	o := &occurrence{fingerprint: fingerprint, name: name(err), start: now, count: 1}
+	l.occurrences[fingerprint] = o
+	l.windows = append(l.windows, o)
+	l.out.Printf("%+v", err)
+}
+
+// Flush prints the summaries of the windows that have ended.
+func (l *Logger) Flush() {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.flush(now)
+}
+
+// Close prints the summaries of all windows, ended or not.
+func (l *Logger) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.flush(time.Time{})
+}
+
+// flush prints the summaries of the windows ended at now, or of all windows
+// if now is zero.
+func (l *Logger) flush(now time.Time) {
+	for len(l.windows) > 0 {
+		o := l.windows[0]
+		if !now.IsZero() && now.Before(o.start.Add(l.window)) {
+			break
+		}
+		l.windows[0] = nil
+		l.windows = l.windows[1:]
+		delete(l.occurrences, o.fingerprint)
+		if o.sample != nil {
+			l.out.Printf("%s occurred %s times in the last %s, sample:\n%+v",
+				o.name, formatCount(o.count), formatWindow(l.window), o.sample)
+		}
+	}
+}
+
+// Run prints the summaries periodically until ctx is done, then prints
+// the remaining ones.
+func (l *Logger) Run(ctx context.Context) {
+	interval := l.window / 2
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			l.Close()
+			return
+		case <-ticker.C:
+			l.Flush()
+		}
+	}
+}
+
+// name returns the code of err, or its message if it has none.
+func name(err error) string {
+	if code, ok := errors.GetCode(err); ok && code != "" {
+		return code
+	}
+	return strconv.Quote(err.Error())
+}
+
+// formatCount formats n with thousands separators, such as 1,532.
+func formatCount(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+func formatWindow(d time.Duration) string {
+	if d >= time.Second && d%time.Second == 0 {
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+	return d.String()
+}
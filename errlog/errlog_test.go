@@ -0,0 +1,87 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errlog_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nextf/errors"
+	"github.com/nextf/errors/errlog"
+)
+
+type printer struct{ lines []string }
+
+func (p *printer) Printf(format string, v ...interface{}) {
+	p.lines = append(p.lines, fmt.Sprintf(format, v...))
+}
+
+func findOrder(id int) error {
+	return errors.ErrCodef("NF_BIS_Order", "Not found order %d", id)
+}
+
+func TestLogger(t *testing.T) {
+	out := &printer{}
+	now := time.Unix(0, 0)
+	l := errlog.NewWithClock(out, time.Minute, func() time.Time { return now })
+	for i := 0; i < 1532; i++ {
+		l.Log(findOrder(i))
+	}
+	l.Log(nil)
+	l.Log(errors.New("Timeout"))
+	now = now.Add(30 * time.Second)
+	l.Log(errors.New("Timeout"))
+	l.Flush()
+	if len(out.lines) != 2 || out.lines[0] != "[NF_BIS_Order] Not found order 0" || out.lines[1] != "Timeout" {
+		t.Fatalf("Unexpected output %q", out.lines)
+	}
+
+	now = now.Add(30 * time.Second)
+	l.Flush()
+	want := "NF_BIS_Order occurred 1,532 times in the last 60s, sample:\n[NF_BIS_Order] Not found order 1531"
+	if len(out.lines) != 4 || out.lines[2] != want || out.lines[3] != "\"Timeout\" occurred 2 times in the last 60s, sample:\nTimeout" {
+		t.Fatalf("Unexpected output %q", out.lines)
+	}
+
+	l.Log(findOrder(1))
+	l.Close()
+	if len(out.lines) != 5 || out.lines[4] != "[NF_BIS_Order] Not found order 1" {
+		t.Errorf("Unexpected output %q", out.lines)
+	}
+}
+
+func TestRun(t *testing.T) {
+	var buf bytes.Buffer
+	l := errlog.New(log.New(&buf, "", 0), 10*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		l.Run(ctx)
+		close(done)
+	}()
+	l.Log(findOrder(1))
+	l.Log(findOrder(2))
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-done
+	if !strings.Contains(buf.String(), "NF_BIS_Order occurred 2 times in the last 10ms, sample:\n[NF_BIS_Order] Not found order 2\n") {
+		t.Errorf("Unexpected output\n%s", buf.String())
+	}
+}
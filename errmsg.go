@@ -34,11 +34,15 @@ func (c *errorMessage) Unwrap() error {
 }
 
 func (c *errorMessage) Format(s fmt.State, verb rune) {
+	format(s, verb, c, c)
+}
+
+func (c *errorMessage) formatChain(s fmt.State, verb rune, ch chain) {
 	switch verb {
 	case 'v':
 		io.WriteString(s, c.message)
 		if s.Flag('+') && c.cause != nil {
-			fmt.Fprintf(s, "\nCaused by: %+v", c.cause)
+			fmt.Fprintf(s, "\nCaused by: %+v", ch.link(c.cause))
 		}
 	case 's':
 		io.WriteString(s, c.message)
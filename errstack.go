@@ -27,6 +27,9 @@ const (
 type errorStack struct {
 	stack stack.CallStack
 	cause error
+	// sampled reports whether the call stack was recorded under a sampling
+	// rule, which may have truncated it to the frame of the caller.
+	sampled bool
 }
 
 func (c *errorStack) StackTrace() []stack.Frame {
@@ -43,15 +46,23 @@ func (c *errorStack) Unwrap() error {
 }
 
 func (c *errorStack) Format(s fmt.State, verb rune) {
+	format(s, verb, c, c)
+}
+
+func (c *errorStack) formatChain(s fmt.State, verb rune, ch chain) {
 	switch verb {
 	case 'v':
 		if s.Flag('-') {
 			// Skip stack trace
 			if c.cause != nil {
-				fmt.Fprintf(s, "%-v", c.cause)
+				fmt.Fprintf(s, "%-v", ch.link(c.cause))
 			}
 			break
 		}
+		if ch.skipStack(c.cause) {
+			fmt.Fprintf(s, causeFormat(s, verb), ch.link(c.cause))
+			break
+		}
 		// Print stack trace
 		width, hasWidth := s.Width()
 		formatCallStack := "@callstack\n%+v"
@@ -64,7 +75,7 @@ func (c *errorStack) Format(s fmt.State, verb rune) {
 			if hasWidth {
 				formatCause = fmt.Sprintf("\nCaused by: %%+%dv", width)
 			}
-			fmt.Fprintf(s, formatCause, c.cause)
+			fmt.Fprintf(s, formatCause, ch.link(c.cause))
 		}
 	case 's':
 		if c.cause != nil {
@@ -77,10 +88,15 @@ func (c *errorStack) Format(s fmt.State, verb rune) {
 	}
 }
 
-func newErrorStack(skip int) error {
-	return &errorStack{stack.RecordCallStack(skip+1, maxDepth), nil}
+// newErrorStack records the call stack of an error with code, sampled by the
+// sampling registered for code.
+func newErrorStack(skip int, code string) error {
+	return withErrorStack(nil, skip+1, code)
 }
 
-func withErrorStack(err error, skip int) error {
-	return &errorStack{stack.RecordCallStack(skip+1, maxDepth), err}
+// withErrorStack annotates err with the call stack of an error with code,
+// sampled by the sampling registered for code.
+func withErrorStack(err error, skip int, code string) error {
+	depth, sampled := stackDepth(code)
+	return &errorStack{stack.RecordCallStack(skip+1, depth), err, sampled}
 }
@@ -0,0 +1,120 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"fmt"
+	"hash/fnv"
+	"path"
+	"regexp"
+	"strconv"
+
+	"github.com/nextf/errors/stack"
+)
+
+// FingerprintOptions configures Fingerprint.
+type FingerprintOptions struct {
+	// Frames is the number of frames of the innermost call stack that
+	// identify the failure, counted from the top.
+	Frames int
+	// Lines includes the line numbers of the frames, so that failures at
+	// different lines of a function are told apart.
+	Lines bool
+	// Messages includes the messages of the errors without code, with their
+	// numbers and quoted strings masked. By default they are identified by
+	// their type only.
+	Messages bool
+}
+
+// DefaultFingerprintOptions are the options used by Fingerprint.
+var DefaultFingerprintOptions = FingerprintOptions{Frames: 5}
+
+// Fingerprint returns a stable hash identifying the failure that caused err,
+// so that identical failures can be grouped together.
+//
+// The fingerprint is computed from the codes in err's chain and the top
+// frames of the innermost call stack, by function and file. Messages are
+// ignored, so errors created by ErrCodef with varying arguments have the same
+// fingerprint. See FingerprintWith for the options. Only the top frame of the
+// call stacks recorded under a sampling registered by RegisterSampling is
+// used, as the errors that are not sampled record no other frame.
+//
+// If err is nil, Fingerprint returns "".
+func Fingerprint(err error) string {
+	return FingerprintWith(err, DefaultFingerprintOptions)
+}
+
+var regForMessageVariable = regexp.MustCompile(`"[^"]*"|'[^']*'|0x[0-9A-Fa-f]+|[0-9]+`)
+
+// FingerprintWith is like Fingerprint with options.
+func FingerprintWith(err error, opts FingerprintOptions) string {
+	if err == nil {
+		return ""
+	}
+	h := fnv.New64a()
+	write := func(s string) {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	var (
+		innermost stack.CallStack
+		sampled   bool
+	)
+	walk(err, func(link error, _ int) bool {
+		if x, ok := link.(interface{ Code() string }); ok && x.Code() != "" {
+			write("code:" + x.Code())
+		} else if !hasStackTrace(link) || next(link) == nil {
+			if opts.Messages {
+				write("message:" + regForMessageVariable.ReplaceAllString(link.Error(), "#"))
+			} else {
+				write(fmt.Sprintf("type:%T", link))
+			}
+		}
+		if cs, ok := callStack(link); ok {
+			innermost = cs
+			x, ok := link.(*errorStack)
+			sampled = ok && x.sampled
+		}
+		return true
+	})
+	if innermost != nil && opts.Frames > 0 {
+		frames := innermost.StackTrace()
+		n := opts.Frames
+		if sampled {
+			// Only the frame of the caller is recorded by every error
+			// that is not sampled.
+			n = 1
+		}
+		if len(frames) > n {
+			frames = frames[:n]
+		}
+		for _, frame := range frames {
+			s := frame.Function + " " + path.Base(frame.File)
+			if opts.Lines {
+				s += ":" + strconv.Itoa(frame.Line)
+			}
+			write("frame:" + s)
+		}
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// callStack returns the call stack of err itself, if any.
+func callStack(err error) (stack.CallStack, bool) {
+	if x, ok := err.(*errorStack); ok {
+		return x.stack, true
+	}
+	return foreignCallStack(err)
+}
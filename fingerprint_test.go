@@ -0,0 +1,76 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors_test
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/nextf/errors"
+)
+
+func findOrder(id int) error {
+	return errors.TraceableErrCodef("NF_BIS_Order", "Not found order %d", id)
+}
+
+func queryOrder(id int) error {
+	return errors.WithErrCodef(findOrder(id), "SVC_Order", "Query order %d failed", id)
+}
+
+func TestFingerprint(t *testing.T) {
+	if fp := errors.Fingerprint(nil); fp != "" {
+		t.Errorf("Expect %q, got %q", "", fp)
+	}
+	fp := errors.Fingerprint(queryOrder(1))
+	if len(fp) != 16 {
+		t.Errorf("Expect 16 hexadecimal digits, got %q", fp)
+	}
+	if other := errors.Fingerprint(queryOrder(2)); other != fp {
+		t.Errorf("Expect %s, got %s", fp, other)
+	}
+	if other := errors.Fingerprint(findOrder(1)); other == fp {
+		t.Errorf("Expect a different fingerprint without SVC_Order")
+	}
+	if other := errors.Fingerprint(errors.WithErrCode(errors.TraceableErrCode("NF_BIS_Order", "Not found"), "SVC_Order", "Query failed")); other == fp {
+		t.Errorf("Expect a different fingerprint for a different call stack")
+	}
+
+	// Line numbers
+	e1 := findOrder(1)
+	e2 := findOrder(1)
+	if errors.Fingerprint(e1) != errors.Fingerprint(e2) {
+		t.Errorf("Expect the same fingerprint ignoring line numbers")
+	}
+	withLines := errors.FingerprintOptions{Frames: 5, Lines: true}
+	if errors.FingerprintWith(e1, withLines) == errors.FingerprintWith(e2, withLines) {
+		t.Errorf("Expect a different fingerprint with line numbers")
+	}
+}
+
+func TestFingerprintMessages(t *testing.T) {
+	e1 := errors.Trace(&os.PathError{Op: "open", Path: "/tmp/1", Err: os.ErrNotExist})
+	e2 := errors.Trace(io.ErrUnexpectedEOF)
+	if errors.FingerprintWith(e1, errors.FingerprintOptions{}) == errors.FingerprintWith(e2, errors.FingerprintOptions{}) {
+		t.Errorf("Expect a different fingerprint for a different type")
+	}
+	opts := errors.FingerprintOptions{Messages: true}
+	if errors.FingerprintWith(errors.New(`read "a.txt" at 12`), opts) != errors.FingerprintWith(errors.New(`read "b.txt" at 3`), opts) {
+		t.Errorf("Expect the same fingerprint with masked messages")
+	}
+	if errors.FingerprintWith(errors.New("read"), opts) == errors.FingerprintWith(errors.New("write"), opts) {
+		t.Errorf("Expect a different fingerprint for a different message")
+	}
+}
@@ -40,6 +40,37 @@ func Is(err, target error) bool {
 	return stderr.Is(err, target)
 }
 
+// CodeTarget returns a target for Is matching the errors with code, so that
+// errors with the same code are equivalent even if they were constructed
+// again, for example after being deserialized:
+//
+//	var ErrNotFoundOrders = errors.CodeTarget("NF_BIS_Order")
+//
+//	errors.Is(errors.ErrCode("NF_BIS_Order", "Not found"), ErrNotFoundOrders) // true
+//
+// It is matched by the errors created by this package and ConstError, which
+// otherwise match the targets equal to them only.
+func CodeTarget(code string) error {
+	return codeTarget(code)
+}
+
+type codeTarget string
+
+func (t codeTarget) Error() string {
+	return "[" + string(t) + "]"
+}
+
+// Code returns the code matched by the target.
+func (t codeTarget) Code() string {
+	return string(t)
+}
+
+// sameCode reports whether target is a CodeTarget of the non-empty code.
+func sameCode(code string, target error) bool {
+	t, ok := target.(codeTarget)
+	return ok && code != "" && string(t) == code
+}
+
 // As finds the first error in err's chain that matches target, and if so, sets
 // target to that error value and returns true. Otherwise, it returns false.
 //
@@ -67,10 +98,25 @@ func Unwrap(err error) error {
 	return stderr.Unwrap(err)
 }
 
+// Cause returns the last error in err's chain, the one that does not wrap
+// another error, or the last error before the chain refers to an error already
+// seen or exceeds the maximum depth. Like Match, Cause follows Cause methods
+// of errors that have no Unwrap method.
+// If err is nil, Cause returns nil.
+func Cause(err error) error {
+	var cause error
+	walk(err, func(link error, _ int) bool {
+		cause = link
+		return next(link) != nil
+	})
+	return cause
+}
+
 // Match reports whether any error in err's chain matches key.
 //
-// The chain consists of err itself followed by the sequence of errors obtained by
-// repeatedly calling Unwrap.
+// The chain consists of err itself followed by the errors obtained by repeatedly
+// calling Unwrap, or Cause for errors that have no Unwrap method, in the order
+// of Walk.
 //
 // An error if it implements a method Match(key) bool such that Match(target)
 // returns true.
@@ -83,58 +129,141 @@ func Unwrap(err error) error {
 //	}
 //
 // then Match(MyError{code:"ERR001"}, "ERR001") returns true.
+//
+// The errors of this package match any key accepted by Compile, such as a
+// code, a glob pattern "AD_*", a regexp, a predicate func(code string) bool,
+// or the Matchers returned by AnyOf, And, Or and Not.
+//
+// A key returned by Outermost or Innermost only matches the first or the last
+// error in err's chain that implements a method Code() string.
 func Match(err error, target interface{}) bool {
-	if err == nil {
-		return false
-	}
-	for {
-		if x, ok := err.(interface{ Match(interface{}) bool }); ok && x.Match(target) {
-			return true
-		}
-		if err = Unwrap(err); err == nil {
-			return false
+	return matchLink(err, target, nil) != nil
+}
+
+// matchLink returns the first error in err's chain matching key, or nil.
+// If accept is not nil, the errors it rejects are skipped.
+func matchLink(err error, key interface{}, accept func(error) bool) error {
+	if k, ok := key.(PositionKey); ok {
+		if link := k.link(err); link != nil && matchOne(link, k.key, accept) {
+			return link
 		}
+		return nil
 	}
+	return Find(err, func(link error) bool {
+		return matchOne(link, key, accept)
+	})
+}
+
+func matchOne(err error, key interface{}, accept func(error) bool) bool {
+	x, ok := err.(interface{ Match(interface{}) bool })
+	return ok && x.Match(key) && (accept == nil || accept(err))
 }
 
 // GetCode finds the first error in err's chain that implements a method Code() string,
 // and if so, returns the code extracted from error and the boolean is true.
 // Otherwise the returned value will be empty and the boolean will be false.
 func GetCode(err error) (string, bool) {
-	if err == nil {
-		return "", false
+	if x := Find(err, isCoded); x != nil {
+		return x.(interface{ Code() string }).Code(), true
+	}
+	return "", false
+}
+
+func isCoded(err error) bool {
+	_, ok := err.(interface{ Code() string })
+	return ok
+}
+
+// RootCode finds the last error in err's chain that implements a method
+// Code() string, the coded root cause, and if so, returns the code extracted
+// from error and the boolean is true.
+// Otherwise the returned value will be empty and the boolean will be false.
+func RootCode(err error) (string, bool) {
+	if x := innermostCoded(err); x != nil {
+		return x.(interface{ Code() string }).Code(), true
 	}
-	for {
-		if x, ok := err.(interface{ Code() string }); ok {
-			return x.Code(), true
+	return "", false
+}
+
+// CodePath returns the non-empty codes of the errors in err's chain,
+// outermost first, such as
+//
+//	[API_Order SVC_Pricing DB_Timeout]
+func CodePath(err error) []string {
+	var path []string
+	walk(err, func(link error, _ int) bool {
+		if x, ok := link.(interface{ Code() string }); ok && x.Code() != "" {
+			path = append(path, x.Code())
 		}
-		if err = Unwrap(err); err == nil {
-			return "", false
+		return true
+	})
+	return path
+}
+
+func innermostCoded(err error) error {
+	var coded error
+	walk(err, func(link error, _ int) bool {
+		if isCoded(link) {
+			coded = link
 		}
+		return true
+	})
+	return coded
+}
+
+// PositionKey is a key of Match restricted to one error of the chain.
+//
+// It is only accepted by Match and Switch.Case, which match chains. Compile,
+// And, Or, Not, RegisterClass and RegisterSampling match the code of each
+// error separately, and panic on a PositionKey.
+type PositionKey struct {
+	key       interface{}
+	innermost bool
+}
+
+// Outermost returns a key of Match that only matches key against the first
+// error in the chain implementing a method Code() string, the one whose code
+// GetCode returns.
+func Outermost(key interface{}) PositionKey {
+	return PositionKey{key, false}
+}
+
+// Innermost returns a key of Match that only matches key against the last
+// error in the chain implementing a method Code() string, the one whose code
+// RootCode returns.
+func Innermost(key interface{}) PositionKey {
+	return PositionKey{key, true}
+}
+
+// link returns the error of err's chain the key is restricted to, or nil.
+func (k PositionKey) link(err error) error {
+	if k.innermost {
+		return innermostCoded(err)
 	}
+	return Find(err, isCoded)
 }
 
 // ErrCode returns an error with error code and message.
 func ErrCode(code, message string) error {
-	return &withErrCode{code, message, nil}
+	return built("ErrCode", code, nil, &withErrCode{code, message, nil})
 }
 
 // ErrCodef returns an error with an error code and a message that is formatted
 // according to the format specifier.
 func ErrCodef(code, format string, args ...interface{}) error {
-	return &withErrCode{code, fmt.Sprintf(format, args...), nil}
+	return built("ErrCodef", code, nil, &withErrCode{code, fmt.Sprintf(format, args...), nil})
 }
 
 // TraceableErrCode returns an error with call stack information and error code
 // and message.
 func TraceableErrCode(code, message string) error {
-	return &withErrCode{code, message, newErrorStack(1)}
+	return built("TraceableErrCode", code, nil, &withErrCode{code, message, newErrorStack(1, code)})
 }
 
 // TraceableErrCodef returns an error with call stack information and error code
 // and a message formatted according to the format specifier.
 func TraceableErrCodef(code, format string, args ...interface{}) error {
-	return &withErrCode{code, fmt.Sprintf(format, args...), newErrorStack(1)}
+	return built("TraceableErrCodef", code, nil, &withErrCode{code, fmt.Sprintf(format, args...), newErrorStack(1, code)})
 }
 
 // WithErrCode annotates err with an error code and message.
@@ -143,7 +272,7 @@ func WithErrCode(err error, code, message string) error {
 	if err == nil {
 		return nil
 	}
-	return &withErrCode{code, message, err}
+	return built("WithErrCode", code, err, &withErrCode{code, message, err})
 }
 
 // WithErrCode annotates err with an error code and a message that is formatted
@@ -153,29 +282,33 @@ func WithErrCodef(err error, code, format string, args ...interface{}) error {
 	if err == nil {
 		return nil
 	}
-	return &withErrCode{code, fmt.Sprintf(format, args...), err}
+	return built("WithErrCodef", code, err, &withErrCode{code, fmt.Sprintf(format, args...), err})
 }
 
 // HasStackTrace reports whether has call stack information in err's chain.
+// Errors of github.com/pkg/errors with a StackTrace method are also recognized.
 func HasStackTrace(err error) bool {
-	if err == nil {
-		return false
+	return Find(err, hasStackTrace) != nil
+}
+
+// StackTrace returns the call stack information of err itself, not of the
+// errors in its chain, and reports whether it has any. Errors of
+// github.com/pkg/errors with a StackTrace method are also recognized.
+func StackTrace(err error) ([]stack.Frame, bool) {
+	if x, ok := err.(interface{ StackTrace() []stack.Frame }); ok {
+		return x.StackTrace(), true
 	}
-	for {
-		if _, ok := err.(interface{ StackTrace() []stack.Frame }); ok {
-			return true
-		}
-		if err = Unwrap(err); err == nil {
-			return false
-		}
+	if pcs, ok := foreignCallStack(err); ok {
+		return pcs.StackTrace(), true
 	}
+	return nil, false
 }
 
-func withStackIfAbsent(err error, skip int) error {
+func withStackIfAbsent(err error, code string, skip int) error {
 	if HasStackTrace(err) {
 		return err
 	} else {
-		return withErrorStack(err, skip+1)
+		return withErrorStack(err, skip+1, code)
 	}
 }
 
@@ -186,7 +319,7 @@ func TraceNodup(err error) error {
 	if err == nil {
 		return nil
 	}
-	return withStackIfAbsent(err, 1)
+	return built("TraceNodup", "", err, withStackIfAbsent(err, samplingCode(err), 1))
 }
 
 // Trace annotates err with a call stack information at the point Trace was called.
@@ -195,7 +328,7 @@ func Trace(err error) error {
 	if err == nil {
 		return nil
 	}
-	return withErrorStack(err, 1)
+	return built("Trace", "", err, withErrorStack(err, 1, samplingCode(err)))
 }
 
 // WrapNodup returns an error annotating err with a call stack information
@@ -207,8 +340,8 @@ func WrapNodup(err error, code, message string) error {
 	if err == nil {
 		return nil
 	}
-	err = withStackIfAbsent(err, 1)
-	return &withErrCode{code, message, err}
+	traced := withStackIfAbsent(err, code, 1)
+	return built("WrapNodup", code, err, &withErrCode{code, message, traced})
 }
 
 // WrapNodupf returns an error annotating err with a call stack information
@@ -221,8 +354,8 @@ func WrapNodupf(err error, code, format string, args ...interface{}) error {
 	if err == nil {
 		return nil
 	}
-	err = withStackIfAbsent(err, 1)
-	return &withErrCode{code, fmt.Sprintf(format, args...), err}
+	traced := withStackIfAbsent(err, code, 1)
+	return built("WrapNodupf", code, err, &withErrCode{code, fmt.Sprintf(format, args...), traced})
 }
 
 // Wrap returns an error annotating err with a call stack information
@@ -232,7 +365,7 @@ func Wrap(err error, code, message string) error {
 	if err == nil {
 		return nil
 	}
-	return &withErrCode{code, message, withErrorStack(err, 1)}
+	return built("Wrap", code, err, &withErrCode{code, message, withErrorStack(err, 1, code)})
 }
 
 // Wrapf returns an error annotating err with a call stack information
@@ -243,7 +376,37 @@ func Wrapf(err error, code, format string, args ...interface{}) error {
 	if err == nil {
 		return nil
 	}
-	return &withErrCode{code, fmt.Sprintf(format, args...), withErrorStack(err, 1)}
+	return built("Wrapf", code, err, &withErrCode{code, fmt.Sprintf(format, args...), withErrorStack(err, 1, code)})
+}
+
+// WrapDeferred annotates *err with a call stack information at the function
+// that deferred WrapDeferred, and an error code and a message that is
+// formatted according to the format specifier. It is meant to be deferred
+// by functions with a named error result:
+//
+//	func readConfig(name string) (err error) {
+//		defer errors.WrapDeferred(&err, "IO_TEC_ReadConfig", "read config %s", name)
+//		...
+//	}
+//
+// The arguments are evaluated when the defer statement is executed.
+// If *err is nil, WrapDeferred does nothing.
+func WrapDeferred(err *error, code, format string, args ...interface{}) {
+	if err == nil || *err == nil {
+		return
+	}
+	*err = built("WrapDeferred", code, *err, &withErrCode{code, fmt.Sprintf(format, args...), withErrorStack(*err, 1, code)})
+}
+
+// WrapNodupDeferred is like WrapDeferred, except that the call stack
+// information is not repeated if *err already contains it, like WrapNodup.
+// If *err is nil, WrapNodupDeferred does nothing.
+func WrapNodupDeferred(err *error, code, format string, args ...interface{}) {
+	if err == nil || *err == nil {
+		return
+	}
+	traced := withStackIfAbsent(*err, code, 1)
+	*err = built("WrapNodupDeferred", code, *err, &withErrCode{code, fmt.Sprintf(format, args...), traced})
 }
 
 // New returns an error with the supplied message.
@@ -259,12 +422,53 @@ func Errorf(format string, args ...interface{}) error {
 	return ConstError(fmt.Sprintf(format, args...))
 }
 
-// Deprecated: Too simple. Use errors.Wrap instead.
+// Annotate annotates err with a message, keeping the error code of err:
+// GetCode and the %v header of the result report the code of err.
+// If err is nil, Annotate returns nil.
+func Annotate(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	return built("Annotate", "", err, &withAnnotation{message, err})
+}
+
+// Annotatef annotates err with a message that is formatted according to the
+// format specifier, keeping the error code of err.
+// If err is nil, Annotatef returns nil.
+func Annotatef(err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return built("Annotatef", "", err, &withAnnotation{fmt.Sprintf(format, args...), err})
+}
+
+// AnnotateNodup annotates err with a message, keeping the error code of err,
+// and with a call stack information at the point AnnotateNodup was called
+// if err does not contain call stack information yet.
+// If err is nil, AnnotateNodup returns nil.
+func AnnotateNodup(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	return built("AnnotateNodup", "", err, &withAnnotation{message, withStackIfAbsent(err, samplingCode(err), 1)})
+}
+
+// AnnotateNodupf is like AnnotateNodup with a message that is formatted
+// according to the format specifier.
+// If err is nil, AnnotateNodupf returns nil.
+func AnnotateNodupf(err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return built("AnnotateNodupf", "", err, &withAnnotation{fmt.Sprintf(format, args...), withStackIfAbsent(err, samplingCode(err), 1)})
+}
+
+// Deprecated: Use errors.AnnotateNodup instead, which keeps the error code of err.
 func TraceMessage(err error, message string) error {
-	return &errorMessage{message, withStackIfAbsent(err, 1)}
+	return built("TraceMessage", "", err, &errorMessage{message, withStackIfAbsent(err, samplingCode(err), 1)})
 }
 
-// Deprecated: Too simple. Use errors.Wrapf instead.
+// Deprecated: Use errors.AnnotateNodupf instead, which keeps the error code of err.
 func TraceMessagef(err error, format string, args ...interface{}) error {
-	return &errorMessage{fmt.Sprintf(format, args...), withStackIfAbsent(err, 1)}
+	return built("TraceMessagef", "", err, &errorMessage{fmt.Sprintf(format, args...), withStackIfAbsent(err, samplingCode(err), 1)})
 }
@@ -346,3 +346,156 @@ func TestTraceNodup(t *testing.T) {
 		t.Errorf("Expect %d, got %d", 2, level)
 	}
 }
+
+func TestCause(t *testing.T) {
+	root := errors.New("[ROOT_ERROR] Root level")
+	err := errors.Wrap(errors.WithErrCode(root, "L1", "Level 1"), "L2", "Level 2")
+	if cause := errors.Cause(err); cause != root {
+		t.Errorf("Expect %v, got %v", root, cause)
+	}
+	if cause := errors.Cause(root); cause != root {
+		t.Errorf("Expect %v, got %v", root, cause)
+	}
+	if cause := errors.Cause(nil); cause != nil {
+		t.Errorf("Expect %v, got %v", nil, cause)
+	}
+}
+
+func TestCodeTarget(t *testing.T) {
+	const errNotFoundOrders = errors.ConstError("[NF_BIS_Order] Not found orders")
+	err := errors.Wrap(errors.ErrCode("NF_BIS_Order", "Not found"), "SVC_Order", "Query failed")
+	if errors.Is(err, errNotFoundOrders) || errors.Is(err, errors.ErrCode("NF_BIS_Order", "Not found")) {
+		t.Errorf("Expect %v, got %v", false, true)
+	}
+
+	target := errors.CodeTarget("NF_BIS_Order")
+	if !errors.Is(err, target) {
+		t.Errorf("Expect %v, got %v", true, false)
+	}
+	if !errors.Is(errNotFoundOrders, target) {
+		t.Errorf("Expect %v, got %v", true, false)
+	}
+	if errors.Is(err, errors.CodeTarget("NF_BIS_Payment")) || errors.Is(errors.New("No code"), errors.CodeTarget("")) {
+		t.Errorf("Expect %v, got %v", false, true)
+	}
+	if code, ok := errors.GetCode(target); !ok || code != "NF_BIS_Order" {
+		t.Errorf("Expect code=%s, got [%s]", "NF_BIS_Order", code)
+	}
+}
+
+func readConfig(name string, cause error) (err error) {
+	defer errors.WrapDeferred(&err, "IO_TEC_ReadConfig", "Read config %s", name)
+	return cause
+}
+
+func readConfigNodup(cause error) (err error) {
+	defer errors.WrapNodupDeferred(&err, "IO_TEC_ReadConfig", "Read config")
+	return cause
+}
+
+func TestWrapDeferred(t *testing.T) {
+	if err := readConfig("app.yaml", nil); err != nil {
+		t.Errorf("Expect %v, got %v", nil, err)
+	}
+	errors.WrapDeferred(nil, "IO_TEC_ReadConfig", "Read config")
+	root := errors.New("[ROOT_ERROR] Root level")
+	err := readConfig("app.yaml", root)
+	if code, ok := errors.GetCode(err); !ok || code != "IO_TEC_ReadConfig" {
+		t.Errorf("Expect code=%s, got [%s]", "IO_TEC_ReadConfig", code)
+	}
+	if err.Error() != "Read config app.yaml" || !errors.Is(err, root) {
+		t.Errorf("Expect `%s`, got `%s`", "Read config app.yaml", err.Error())
+	}
+	frames := errors.Unwrap(err).(interface{ StackTrace() []stack.Frame }).StackTrace()
+	if frames[0].Function != "github.com/nextf/errors_test.readConfig" {
+		t.Errorf("Expect the call stack at %s, got %s", "readConfig", frames[0].Describe())
+	}
+
+	traced := errors.Trace(root)
+	err = readConfigNodup(traced)
+	if errors.Unwrap(err) != traced {
+		t.Errorf("Expect the call stack not to be repeated")
+	}
+	err = readConfigNodup(root)
+	if frames := errors.Unwrap(err).(interface{ StackTrace() []stack.Frame }).StackTrace(); frames[0].Function != "github.com/nextf/errors_test.readConfigNodup" {
+		t.Errorf("Expect the call stack at %s, got %s", "readConfigNodup", frames[0].Describe())
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	root := errors.ErrCode("NF_BIS_Order", "Not found order")
+	err := errors.Annotatef(errors.Annotate(root, "Query order"), "Load order %d", 1)
+	if code, ok := errors.GetCode(err); !ok || code != "NF_BIS_Order" {
+		t.Errorf("Expect code=%s, got [%s]", "NF_BIS_Order", code)
+	}
+	if err.Error() != "Load order 1" || !errors.Match(err, "NF_BIS_Order") || errors.HasStackTrace(err) {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if text := fmt.Sprintf("%v", err); text != "[NF_BIS_Order] Load order 1" {
+		t.Errorf("Expect %s, got %s", "[NF_BIS_Order] Load order 1", text)
+	}
+	want := "[NF_BIS_Order] Load order 1\nCaused by: [NF_BIS_Order] Query order\nCaused by: [NF_BIS_Order] Not found order"
+	if text := fmt.Sprintf("%+v", err); text != want {
+		t.Errorf("Expect\n%s\ngot\n%s", want, text)
+	}
+	if text := fmt.Sprintf("%v", errors.Annotate(errors.New("Root level"), "Level 1")); text != "Level 1" {
+		t.Errorf("Expect %s, got %s", "Level 1", text)
+	}
+	if errors.Annotate(nil, "Level 1") != nil || errors.AnnotateNodupf(nil, "Level %d", 1) != nil {
+		t.Errorf("Expect nil")
+	}
+}
+
+func TestAnnotateNodup(t *testing.T) {
+	root := errors.ErrCode("NF_BIS_Order", "Not found order")
+	err := errors.AnnotateNodup(root, "Level 1")
+	for i := 2; i < 10; i++ {
+		err = errors.AnnotateNodupf(err, "Level %d", i)
+	}
+	counter := 0
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if _, ok := e.(interface{ StackTrace() []stack.Frame }); ok {
+			counter++
+		}
+	}
+	if counter != 1 {
+		t.Errorf("Expect %d, got %d", 1, counter)
+	}
+	if code, ok := errors.GetCode(err); !ok || code != "NF_BIS_Order" {
+		t.Errorf("Expect code=%s, got [%s]", "NF_BIS_Order", code)
+	}
+	withStack := fmt.Sprintf("%+v", errors.AnnotateNodup(root, "Level 1"))
+	logPrefix := "[NF_BIS_Order] Level 1\nCaused by: @callstack\n\x20\x20\x20\x20github.com/nextf/errors_test.TestAnnotateNodup(functions_test.go:"
+	if !strings.HasPrefix(withStack, logPrefix) {
+		t.Errorf("Unprinted stack\n%s", withStack)
+	}
+}
+
+func TestCodePath(t *testing.T) {
+	err := errors.Wrap(errors.Annotate(errors.WithErrCode(errors.TraceableErrCode("DB_Timeout", "Timeout"), "SVC_Pricing", "Pricing failed"), "Retry"), "API_Order", "Order failed")
+	if path := errors.CodePath(err); !reflect.DeepEqual(path, []string{"API_Order", "SVC_Pricing", "DB_Timeout"}) {
+		t.Errorf("Expect %v, got %v", []string{"API_Order", "SVC_Pricing", "DB_Timeout"}, path)
+	}
+	if code, ok := errors.RootCode(err); !ok || code != "DB_Timeout" {
+		t.Errorf("Expect code=%s, got [%s]", "DB_Timeout", code)
+	}
+	if code, ok := errors.RootCode(errors.Trace(fmt.Errorf("No code"))); ok {
+		t.Errorf("Expect no code, got [%s]", code)
+	}
+	if path := errors.CodePath(errors.WithErrCode(errors.New("No code"), "L1", "Level 1")); !reflect.DeepEqual(path, []string{"L1"}) {
+		t.Errorf("Expect %v, got %v", []string{"L1"}, path)
+	}
+	if path := errors.CodePath(nil); path != nil {
+		t.Errorf("Expect %v, got %v", nil, path)
+	}
+
+	if !errors.Match(err, errors.Outermost("API_Order")) || errors.Match(err, errors.Outermost("DB_Timeout")) {
+		t.Errorf("Expect only API_Order to match the outermost code")
+	}
+	if !errors.Match(err, errors.Innermost(regexp.MustCompile("^DB_"))) || errors.Match(err, errors.Innermost("SVC_Pricing")) {
+		t.Errorf("Expect only DB_Timeout to match the innermost code")
+	}
+	if errors.Match(fmt.Errorf("No code"), errors.Innermost("")) || errors.Match(nil, errors.Outermost("")) {
+		t.Errorf("Expect no match without code")
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+// AsType finds the first error in err's chain that is of type T, or that
+// implements a method As(interface{}) bool such that As(&t) returns true,
+// and if so, returns it and the boolean is true.
+// Otherwise the returned value will be the zero value and the boolean will
+// be false. For example
+//
+//	if coder, ok := errors.AsType[interface{ Code() string }](err); ok {
+//		log.Print(coder.Code())
+//	}
+//
+// Like Match, AsType walks the chain with Walk.
+func AsType[T any](err error) (T, bool) {
+	var t T
+	found := Find(err, func(link error) bool {
+		if x, ok := link.(T); ok {
+			t = x
+			return true
+		}
+		x, ok := link.(interface{ As(interface{}) bool })
+		return ok && x.As(&t)
+	})
+	return t, found != nil
+}
+
+// Find returns the first error in err's chain, in the order of Walk, for
+// which f returns true, or nil if there is none.
+func Find(err error, f func(error) bool) error {
+	var found error
+	walk(err, func(link error, _ int) bool {
+		if f(link) {
+			found = link
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// Coded is an error with an error code, a message and a payload of type T,
+// such as the details of a validation failure. Create it with PayloadErrCode
+// or WithPayload, and retrieve the payload with Payload.
+type Coded[T any] struct {
+	*withErrCode
+	payload T
+}
+
+// Payload returns the payload of the error.
+func (c *Coded[T]) Payload() T {
+	return c.payload
+}
+
+// PayloadErrCode returns an error with error code, message and payload.
+func PayloadErrCode[T any](code, message string, payload T) error {
+	return built("PayloadErrCode", code, nil, &Coded[T]{&withErrCode{code, message, nil}, payload})
+}
+
+// WithPayload annotates err with an error code, message and payload.
+// If err is nil, WithPayload returns nil.
+func WithPayload[T any](err error, code, message string, payload T) error {
+	if err == nil {
+		return nil
+	}
+	return built("WithPayload", code, err, &Coded[T]{&withErrCode{code, message, err}, payload})
+}
+
+// Payload finds the first error in err's chain that implements a method
+// Payload() T, such as a *Coded[T], and if so, returns its payload and the
+// boolean is true.
+// Otherwise the returned value will be the zero value and the boolean will
+// be false.
+func Payload[T any](err error) (T, bool) {
+	if x, ok := AsType[interface{ Payload() T }](err); ok {
+		return x.Payload(), true
+	}
+	var t T
+	return t, false
+}
@@ -0,0 +1,101 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/nextf/errors"
+)
+
+type fieldErrors struct {
+	Fields map[string]string
+}
+
+type asError struct{ code string }
+
+func (e asError) Error() string { return "as " + e.code }
+
+func (e asError) As(target interface{}) bool {
+	if p, ok := target.(*interface{ Code() string }); ok {
+		*p = errors.ErrCode(e.code, "Converted").(interface{ Code() string })
+		return true
+	}
+	return false
+}
+
+func TestAsType(t *testing.T) {
+	err := errors.Wrap(errors.WithErrCode(&os.PathError{Op: "open", Path: "a.txt", Err: os.ErrNotExist}, "IO_TEC_Open", "Open failed"), "API_Load", "Load failed")
+	if pe, ok := errors.AsType[*os.PathError](err); !ok || pe.Path != "a.txt" {
+		t.Errorf("Expect the *os.PathError, got %v", pe)
+	}
+	if coder, ok := errors.AsType[interface{ Code() string }](err); !ok || coder.Code() != "API_Load" {
+		t.Errorf("Expect code=%s, got %v", "API_Load", coder)
+	}
+	if _, ok := errors.AsType[*fieldErrors](err); ok {
+		t.Errorf("Expect %v, got %v", false, true)
+	}
+	if coder, ok := errors.AsType[interface{ Code() string }](fmt.Errorf("x: %w", asError{"AS_Code"})); !ok || coder.Code() != "AS_Code" {
+		t.Errorf("Expect code=%s, got %v", "AS_Code", coder)
+	}
+	if _, ok := errors.AsType[error](nil); ok {
+		t.Errorf("Expect %v, got %v", false, true)
+	}
+}
+
+func TestFind(t *testing.T) {
+	inner := errors.ErrCode("NF_BIS_Order", "Not found")
+	err := errors.Annotate(errors.Wrap(inner, "API_Order", "Order failed"), "Retry 3")
+	found := errors.Find(err, func(err error) bool {
+		x, ok := err.(interface{ Code() string })
+		return ok && x.Code() == "NF_BIS_Order"
+	})
+	if found != inner {
+		t.Errorf("Expect %v, got %v", inner, found)
+	}
+	if found = errors.Find(err, func(error) bool { return false }); found != nil {
+		t.Errorf("Expect nil, got %v", found)
+	}
+}
+
+func TestPayload(t *testing.T) {
+	details := fieldErrors{map[string]string{"name": "required"}}
+	err := errors.Wrap(errors.PayloadErrCode("BIS_Invalid", "Invalid order", details), "API_Order", "Order failed")
+	if got, ok := errors.Payload[fieldErrors](err); !ok || got.Fields["name"] != "required" {
+		t.Errorf("Expect %v, got %v", details, got)
+	}
+	if _, ok := errors.Payload[*fieldErrors](err); ok {
+		t.Errorf("Expect no *fieldErrors payload")
+	}
+	if code, _ := errors.GetCode(err); code != "API_Order" {
+		t.Errorf("Expect code=%s, got %s", "API_Order", code)
+	}
+	if !errors.Match(err, "BIS_*") {
+		t.Errorf("Expect the payload error to match")
+	}
+
+	coded, ok := errors.AsType[*errors.Coded[int]](errors.WithPayload(errors.New("Too many"), "BIS_Limit", "Limit exceeded", 10))
+	if !ok || coded.Payload() != 10 || coded.Code() != "BIS_Limit" {
+		t.Errorf("Expect the payload 10, got %v", coded)
+	}
+	if s := fmt.Sprintf("%+v", coded); s != "[BIS_Limit] Limit exceeded\nCaused by: Too many" {
+		t.Errorf("Unexpected format %q", s)
+	}
+	if errors.WithPayload(nil, "BIS_Limit", "Limit exceeded", 10) != nil {
+		t.Errorf("Expect nil")
+	}
+}
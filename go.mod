@@ -1,3 +1,4 @@
 module github.com/nextf/errors
 
-go 1.17
+// iter.Seq, returned by All in walk.go, requires go 1.23.
+go 1.23.0
@@ -0,0 +1,114 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/nextf/errors/stack"
+)
+
+// Event describes an error built by a constructor of this package.
+type Event struct {
+	// Op is the name of the constructor, such as "Wrap".
+	Op string
+	// Err is the error returned by the constructor. TraceNodup returns
+	// the error it was given if it already has call stack information.
+	Err error
+	// Cause is the error given to the constructor, nil for ErrCode,
+	// ErrCodef, TraceableErrCode and TraceableErrCodef.
+	Cause error
+	// Code is the error code given to the constructor, if any.
+	Code string
+	// PC identifies the call site of the constructor, as a program counter
+	// reported by runtime.Callers.
+	PC uintptr
+}
+
+// Frame returns the call site of the constructor.
+func (e Event) Frame() stack.Frame {
+	frame, _ := runtime.CallersFrames([]uintptr{e.PC}).Next()
+	return stack.Frame(frame)
+}
+
+// Hook observes the errors built by the constructors in this package.
+//
+// Hooks are called synchronously by the constructors, in the order they
+// were added. They must be safe for concurrent use and fast.
+type Hook func(e Event)
+
+type hookSet struct {
+	hooks []*Hook
+}
+
+var (
+	hooksMu sync.Mutex
+	hooks   atomic.Pointer[hookSet]
+)
+
+// AddHook installs h, and returns a function that removes it.
+func AddHook(h Hook) (remove func()) {
+	p := &h
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	var list []*Hook
+	if set := hooks.Load(); set != nil {
+		list = append(list, set.hooks...)
+	}
+	hooks.Store(&hookSet{append(list, p)})
+	var once sync.Once
+	return func() {
+		once.Do(func() { removeHook(p) })
+	}
+}
+
+func removeHook(p *Hook) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	set := hooks.Load()
+	if set == nil {
+		return
+	}
+	list := make([]*Hook, 0, len(set.hooks))
+	for _, h := range set.hooks {
+		if h != p {
+			list = append(list, h)
+		}
+	}
+	if len(list) == 0 {
+		hooks.Store(nil)
+	} else {
+		hooks.Store(&hookSet{list})
+	}
+}
+
+// built notifies the hooks that the constructor op built err, and returns err.
+// It must be called directly by the constructor.
+func built(op, code string, cause, err error) error {
+	set := hooks.Load()
+	if set == nil {
+		return err
+	}
+	var pc [1]uintptr
+	// Skip runtime.Callers, built and the constructor.
+	runtime.Callers(3, pc[:])
+	e := Event{Op: op, Err: err, Cause: cause, Code: code, PC: pc[0]}
+	for _, h := range set.hooks {
+		(*h)(e)
+	}
+	return err
+}
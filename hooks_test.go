@@ -0,0 +1,95 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors_test
+
+import (
+	"io"
+	"sync/atomic"
+	"testing"
+
+	"github.com/nextf/errors"
+)
+
+func TestAddHook(t *testing.T) {
+	var events []errors.Event
+	remove := errors.AddHook(func(e errors.Event) {
+		events = append(events, e)
+	})
+	var counter int64
+	removeCounter := errors.AddHook(func(e errors.Event) {
+		atomic.AddInt64(&counter, 1)
+	})
+
+	root := errors.ErrCode("ROOT", "Level 0")
+	wrapped := errors.Wrap(root, "L1", "Level 1")
+	traced := errors.TraceNodup(wrapped)
+	errors.WithErrCode(nil, "L2", "Not built")
+	removeCounter()
+	removeCounter()
+	errors.Trace(io.EOF)
+
+	if len(events) != 4 || counter != 3 {
+		t.Fatalf("Expect %d and %d events, got %d and %d", 4, 3, len(events), counter)
+	}
+	if e := events[0]; e.Op != "ErrCode" || e.Err != root || e.Cause != nil || e.Code != "ROOT" {
+		t.Errorf("Unexpected event %+v", e)
+	}
+	if e := events[1]; e.Op != "Wrap" || e.Err != wrapped || e.Cause != root || e.Code != "L1" {
+		t.Errorf("Unexpected event %+v", e)
+	}
+	if e := events[2]; e.Op != "TraceNodup" || e.Err != traced || e.Cause != wrapped || e.Code != "" {
+		t.Errorf("Unexpected event %+v", e)
+	}
+	if e := events[3]; e.Op != "Trace" || e.Cause != io.EOF {
+		t.Errorf("Unexpected event %+v", e)
+	}
+	if frame := events[1].Frame(); frame.Function != "github.com/nextf/errors_test.TestAddHook" || frame.Line != 36 {
+		t.Errorf("Expect %s, got %s", "github.com/nextf/errors_test.TestAddHook(hooks_test.go:36)", frame.Describe())
+	}
+
+	remove()
+	errors.ErrCode("ROOT", "Level 0")
+	if len(events) != 4 {
+		t.Errorf("Expect %d events, got %d", 4, len(events))
+	}
+}
+
+func BenchmarkWrap(b *testing.B) {
+	root := errors.ErrCode("ROOT", "Level 0")
+	b.Run("NoHooks", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			errors.WithErrCode(root, "L1", "Level 1")
+		}
+	})
+	b.Run("Hook", func(b *testing.B) {
+		remove := errors.AddHook(func(e errors.Event) {})
+		defer remove()
+		for i := 0; i < b.N; i++ {
+			errors.WithErrCode(root, "L1", "Level 1")
+		}
+	})
+	b.Run("NoHooksTraced", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			errors.Wrap(root, "L1", "Level 1")
+		}
+	})
+	b.Run("HookTraced", func(b *testing.B) {
+		remove := errors.AddHook(func(e errors.Event) {})
+		defer remove()
+		for i := 0; i < b.N; i++ {
+			errors.Wrap(root, "L1", "Level 1")
+		}
+	})
+}
@@ -0,0 +1,204 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import "strings"
+
+// Matcher is a compiled key of Match that reports whether an error code
+// matches.
+type Matcher interface {
+	MatchString(code string) bool
+}
+
+// MatcherFunc is a predicate on error codes usable as a Matcher.
+type MatcherFunc func(code string) bool
+
+// MatchString reports whether f(code) is true.
+func (f MatcherFunc) MatchString(code string) bool {
+	return f(code)
+}
+
+// Compile compiles a key of Match into a Matcher, so that it can be reused
+// in hot paths. A key may be
+//
+//   - a string, matching a code equal to it, or a glob pattern such as
+//     "AD_*", where * matches any sequence of characters and ? matches any
+//     single character;
+//   - a func(code string) bool predicate;
+//   - anything with a method MatchString(string) bool, such as a
+//     *regexp.Regexp, a *CodeGroup or a Matcher.
+//
+// Any other key, including nil, compiles into a Matcher that matches nothing,
+// except a PositionKey: it restricts Match to one error of the chain, which a
+// Matcher of codes cannot do, so Compile panics on it.
+func Compile(key interface{}) Matcher {
+	mustMatchCodes(key)
+	switch x := key.(type) {
+	case string:
+		if isGlob(x) {
+			return glob(x)
+		}
+		return exactCode(x)
+	case func(string) bool:
+		return MatcherFunc(x)
+	case Matcher:
+		return x
+	}
+	return none{}
+}
+
+// AnyOf returns a Matcher of the codes equal to one of codes.
+func AnyOf(codes ...string) Matcher {
+	set := make(codeSet, len(codes))
+	for _, code := range codes {
+		set[code] = struct{}{}
+	}
+	return set
+}
+
+// And returns a Matcher of the codes matching all keys.
+func And(keys ...interface{}) Matcher {
+	return and(compileAll(keys))
+}
+
+// Or returns a Matcher of the codes matching any of keys.
+func Or(keys ...interface{}) Matcher {
+	return or(compileAll(keys))
+}
+
+// Not returns a Matcher of the codes not matching key.
+//
+// Since Match tries every error in the chain, Match(err, Not(key)) reports
+// whether any coded error in the chain does not match key; use
+// !Match(err, key) to check that none does.
+func Not(key interface{}) Matcher {
+	return not{Compile(key)}
+}
+
+// matchCode reports whether code matches key, without compiling key.
+func matchCode(code string, key interface{}) bool {
+	switch x := key.(type) {
+	case string:
+		if isGlob(x) {
+			return glob(x).MatchString(code)
+		}
+		return code == x
+	case func(string) bool:
+		return x(code)
+	case Matcher:
+		return x.MatchString(code)
+	}
+	return false
+}
+
+// mustMatchCodes panics if key is a PositionKey, for the functions matching
+// codes rather than chains.
+func mustMatchCodes(key interface{}) {
+	if _, ok := key.(PositionKey); ok {
+		panic("errors: a PositionKey only applies to Match, not to the codes of errors")
+	}
+}
+
+func compileAll(keys []interface{}) []Matcher {
+	ms := make([]Matcher, len(keys))
+	for i, key := range keys {
+		ms[i] = Compile(key)
+	}
+	return ms
+}
+
+type none struct{}
+
+func (none) MatchString(string) bool { return false }
+
+type exactCode string
+
+func (c exactCode) MatchString(code string) bool { return string(c) == code }
+
+type codeSet map[string]struct{}
+
+func (s codeSet) MatchString(code string) bool {
+	_, ok := s[code]
+	return ok
+}
+
+type and []Matcher
+
+func (ms and) MatchString(code string) bool {
+	for _, m := range ms {
+		if !m.MatchString(code) {
+			return false
+		}
+	}
+	return true
+}
+
+type or []Matcher
+
+func (ms or) MatchString(code string) bool {
+	for _, m := range ms {
+		if m.MatchString(code) {
+			return true
+		}
+	}
+	return false
+}
+
+type not struct{ m Matcher }
+
+func (n not) MatchString(code string) bool { return !n.m.MatchString(code) }
+
+// Error codes consist of [A-Za-z0-9_-], so a key containing * or ? can only
+// be a pattern.
+func isGlob(key string) bool {
+	return strings.ContainsAny(key, "*?")
+}
+
+type glob string
+
+// MatchString matches code against the pattern, backtracking to the last *
+// on a mismatch.
+func (g glob) MatchString(code string) bool {
+	p, s := string(g), code
+	star, next := -1, 0
+	for i, j := 0, 0; j < len(s) || i < len(p); {
+		if i < len(p) {
+			switch p[i] {
+			case '*':
+				star, next = i, j
+				i++
+				continue
+			case '?':
+				if j < len(s) {
+					i++
+					j++
+					continue
+				}
+			default:
+				if j < len(s) && p[i] == s[j] {
+					i++
+					j++
+					continue
+				}
+			}
+		}
+		if star < 0 || next >= len(s) {
+			return false
+		}
+		next++
+		i, j = star+1, next
+	}
+	return true
+}
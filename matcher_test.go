@@ -0,0 +1,122 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/nextf/errors"
+)
+
+func TestCompile(t *testing.T) {
+	cases := []struct {
+		key  interface{}
+		code string
+		want bool
+	}{
+		{"AD_Login", "AD_Login", true},
+		{"AD_Login", "AD_Logout", false},
+		{"AD_*", "AD_Login", true},
+		{"AD_*", "AD_", true},
+		{"AD_*", "NF_AD_Login", false},
+		{"*_Login", "AD_Login", true},
+		{"*_TEC_*", "IO_TEC_Read", true},
+		{"*_TEC_*", "IO_BIS_Read", false},
+		{"AD_?", "AD_1", true},
+		{"AD_?", "AD_12", false},
+		{"A*B*C", "AxxBxxBxxC", true},
+		{"A*B*C", "AxxBxxCxxD", false},
+		{"*", "", true},
+		{"?", "", false},
+		{func(code string) bool { return strings.HasSuffix(code, "_Login") }, "AD_Login", true},
+		{errors.MatcherFunc(func(code string) bool { return false }), "AD_Login", false},
+		{regexp.MustCompile("^AD_"), "AD_Login", true},
+		{errors.AnyOf("AD_Login", "AD_Logout"), "AD_Logout", true},
+		{errors.AnyOf("AD_Login", "AD_Logout"), "AD_Lock", false},
+		{errors.AnyOf(), "", false},
+		{errors.And("AD_*", errors.Not("AD_Logout")), "AD_Login", true},
+		{errors.And("AD_*", errors.Not("AD_Logout")), "AD_Logout", false},
+		{errors.And(), "AD_Login", true},
+		{errors.Or("NF_*", regexp.MustCompile("Login$")), "AD_Login", true},
+		{errors.Or("NF_*", regexp.MustCompile("Login$")), "AD_Logout", false},
+		{errors.Or(), "AD_Login", false},
+		{errors.Not(nil), "AD_Login", true},
+		{nil, "AD_Login", false},
+		{42, "AD_Login", false},
+	}
+	for _, c := range cases {
+		if got := errors.Compile(c.key).MatchString(c.code); got != c.want {
+			t.Errorf("Compile(%#v).MatchString(%q): expect %v, got %v", c.key, c.code, c.want, got)
+		}
+		err := errors.ErrCode(c.code, "Message")
+		if got := errors.Match(err, c.key); got != c.want && c.code != "" {
+			t.Errorf("Match(%s, %#v): expect %v, got %v", c.code, c.key, c.want, got)
+		}
+	}
+}
+
+func TestCompilePositionKey(t *testing.T) {
+	key := errors.Outermost("NF_*")
+	for name, f := range map[string]func(){
+		"Compile":          func() { errors.Compile(key) },
+		"And":              func() { errors.And("NF_*", key) },
+		"Or":               func() { errors.Or(errors.Innermost("NF_*")) },
+		"Not":              func() { errors.Not(key) },
+		"RegisterClass":    func() { errors.RegisterClass(key, errors.Permanent, 0) },
+		"RegisterSampling": func() { errors.RegisterSampling(key, errors.EveryN(2)) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expect %s to panic on a PositionKey", name)
+				}
+			}()
+			f()
+		}()
+	}
+	if !errors.Match(errors.ErrCode("NF_BIS_Order", "Not found"), key) {
+		t.Errorf("Expect Match to accept a PositionKey")
+	}
+}
+
+func TestMatchConstErrorWithGlob(t *testing.T) {
+	err := errors.Wrap(errors.ConstError("[NF_BIS_Cache] Not cached"), "SVC_TEC_Load", "Load failed")
+	if !errors.Match(err, "NF_*") {
+		t.Errorf("Expect %v, got %v", true, false)
+	}
+	if !errors.Match(err, errors.Innermost(errors.AnyOf("NF_BIS_Cache"))) {
+		t.Errorf("Expect %v, got %v", true, false)
+	}
+	if errors.Match(err, errors.Outermost("NF_*")) {
+		t.Errorf("Expect %v, got %v", false, true)
+	}
+}
+
+func BenchmarkMatchGlob(b *testing.B) {
+	err := errors.Wrap(errors.ErrCode("NF_BIS_Order", "Not found"), "API_TEC_Order", "Order failed")
+	b.Run("string", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			errors.Match(err, "NF_*")
+		}
+	})
+	b.Run("compiled", func(b *testing.B) {
+		m := errors.Or("XX_*", errors.And("NF_*", errors.Not("NF_BIS_Cache")))
+		for i := 0; i < b.N; i++ {
+			errors.Match(err, m)
+		}
+	})
+}
@@ -0,0 +1,176 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrCodeMatcherSyntax is the code of the errors returned by ParseMatcher.
+const ErrCodeMatcherSyntax = "MATCHER_Syntax"
+
+// ParseMatcher compiles a matcher expression, such as
+//
+//	AD_* | NF_BIS_* & !NF_BIS_Cache
+//
+// into a key of Match. An operand is a code or a glob pattern as accepted by
+// Compile. The operators are, from the highest precedence to the lowest,
+// ! (not), & (and) and | (or); parentheses group operands.
+//
+// If expr is not valid, ParseMatcher returns an error with the code
+// ErrCodeMatcherSyntax.
+func ParseMatcher(expr string) (Matcher, error) {
+	p := &exprParser{expr: expr}
+	m, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.pos < len(p.expr) {
+		return nil, p.unexpected()
+	}
+	return m, nil
+}
+
+// MustParseMatcher is like ParseMatcher but panics if expr is not valid.
+// It simplifies safe initialization of global variables holding matchers.
+func MustParseMatcher(expr string) Matcher {
+	m, err := ParseMatcher(expr)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// MatcherExpr is a matcher expression that can be read from configuration
+// files, as it implements encoding.TextUnmarshaler. The zero value matches
+// nothing.
+type MatcherExpr struct {
+	expr string
+	m    Matcher
+}
+
+// MatchString reports whether code matches the expression.
+func (e MatcherExpr) MatchString(code string) bool {
+	return e.m != nil && e.m.MatchString(code)
+}
+
+// String returns the source of the expression.
+func (e MatcherExpr) String() string {
+	return e.expr
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (e MatcherExpr) MarshalText() ([]byte, error) {
+	return []byte(e.expr), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, parsing text with
+// ParseMatcher.
+func (e *MatcherExpr) UnmarshalText(text []byte) error {
+	m, err := ParseMatcher(string(text))
+	if err != nil {
+		return err
+	}
+	e.expr, e.m = string(text), m
+	return nil
+}
+
+type exprParser struct {
+	expr string
+	pos  int
+}
+
+func (p *exprParser) or() (Matcher, error) {
+	return p.list('|', p.and, func(ms []Matcher) Matcher { return or(ms) })
+}
+
+func (p *exprParser) and() (Matcher, error) {
+	return p.list('&', p.unary, func(ms []Matcher) Matcher { return and(ms) })
+}
+
+// list parses operands separated by op.
+func (p *exprParser) list(op byte, operand func() (Matcher, error), join func([]Matcher) Matcher) (Matcher, error) {
+	var ms []Matcher
+	for {
+		m, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, m)
+		if p.skipSpace(); p.pos >= len(p.expr) || p.expr[p.pos] != op {
+			break
+		}
+		p.pos++
+	}
+	if len(ms) == 1 {
+		return ms[0], nil
+	}
+	return join(ms), nil
+}
+
+func (p *exprParser) unary() (Matcher, error) {
+	if p.skipSpace(); p.pos >= len(p.expr) {
+		return nil, p.errorf("missing operand at end of %q", p.expr)
+	}
+	switch p.expr[p.pos] {
+	case '!':
+		p.pos++
+		m, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return not{m}, nil
+	case '(':
+		open := p.pos
+		p.pos++
+		m, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.skipSpace(); p.pos >= len(p.expr) || p.expr[p.pos] != ')' {
+			return nil, p.errorf("missing ) for ( at offset %d in %q", open, p.expr)
+		}
+		p.pos++
+		return m, nil
+	}
+	start := p.pos
+	for p.pos < len(p.expr) && isPatternChar(p.expr[p.pos]) {
+		p.pos++
+	}
+	if start == p.pos {
+		return nil, p.unexpected()
+	}
+	return Compile(p.expr[start:p.pos]), nil
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.expr) && strings.IndexByte(" \t\r\n", p.expr[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *exprParser) unexpected() error {
+	return p.errorf("unexpected %q at offset %d in %q", p.expr[p.pos], p.pos, p.expr)
+}
+
+func (p *exprParser) errorf(format string, args ...interface{}) error {
+	return ErrCode(ErrCodeMatcherSyntax, "invalid matcher expression: "+fmt.Sprintf(format, args...))
+}
+
+func isPatternChar(c byte) bool {
+	return 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+		c == '_' || c == '-' || c == '*' || c == '?'
+}
@@ -0,0 +1,138 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/nextf/errors"
+)
+
+func TestParseMatcher(t *testing.T) {
+	cases := []struct {
+		expr  string
+		match []string
+		miss  []string
+	}{
+		{"AD_Login", []string{"AD_Login"}, []string{"AD_Logout", ""}},
+		{"AD_* | NF_BIS_* & !NF_BIS_Cache", []string{"AD_Login", "NF_BIS_Order"}, []string{"NF_BIS_Cache", "IO_TEC_Read"}},
+		{"(AD_* | NF_BIS_*) & !NF_BIS_Cache", []string{"AD_Login", "NF_BIS_Order"}, []string{"NF_BIS_Cache"}},
+		{"!(AD_* | NF_*)", []string{"IO_TEC_Read"}, []string{"AD_Login", "NF_BIS_Order"}},
+		{"!!AD_*", []string{"AD_Login"}, []string{"NF_BIS_Order"}},
+		{" *_TEC_? ", []string{"IO_TEC_X"}, []string{"IO_TEC_XY"}},
+		{"A&B|C", []string{"C"}, []string{"A", "B"}},
+	}
+	for _, c := range cases {
+		m, err := errors.ParseMatcher(c.expr)
+		if err != nil {
+			t.Errorf("ParseMatcher(%q): %v", c.expr, err)
+			continue
+		}
+		for _, code := range c.match {
+			if !m.MatchString(code) {
+				t.Errorf("ParseMatcher(%q): expect %q to match", c.expr, code)
+			}
+		}
+		for _, code := range c.miss {
+			if m.MatchString(code) {
+				t.Errorf("ParseMatcher(%q): expect %q not to match", c.expr, code)
+			}
+		}
+	}
+
+	err := errors.Wrap(errors.ErrCode("NF_BIS_Order", "Not found"), "API_Order", "Order failed")
+	if !errors.Match(err, errors.MustParseMatcher("AD_* | NF_BIS_* & !NF_BIS_Cache")) {
+		t.Errorf("Expect %v, got %v", true, false)
+	}
+}
+
+func TestParseMatcherError(t *testing.T) {
+	cases := []struct {
+		expr, message string
+	}{
+		{"", `missing operand at end of ""`},
+		{"AD_* |", `missing operand at end of "AD_* |"`},
+		{"AD_* NF_*", `unexpected 'N' at offset 5 in "AD_* NF_*"`},
+		{"(AD_* | NF_*", `missing ) for ( at offset 0 in "(AD_* | NF_*"`},
+		{"AD_*)", `unexpected ')' at offset 4 in "AD_*)"`},
+		{"AD_.*", `unexpected '.' at offset 3 in "AD_.*"`},
+		{"& AD_*", `unexpected '&' at offset 0 in "& AD_*"`},
+	}
+	for _, c := range cases {
+		m, err := errors.ParseMatcher(c.expr)
+		if m != nil || err == nil {
+			t.Errorf("ParseMatcher(%q): expect error, got %v", c.expr, m)
+			continue
+		}
+		if !errors.Match(err, errors.ErrCodeMatcherSyntax) {
+			t.Errorf("ParseMatcher(%q): expect code %s, got %v", c.expr, errors.ErrCodeMatcherSyntax, err)
+		}
+		if !strings.HasSuffix(err.Error(), c.message) {
+			t.Errorf("ParseMatcher(%q): expect %s, got %s", c.expr, c.message, err)
+		}
+	}
+}
+
+func TestMatcherExpr(t *testing.T) {
+	var config struct {
+		Alert errors.MatcherExpr `json:"alert"`
+	}
+	if config.Alert.MatchString("") {
+		t.Errorf("Expect the zero value to match nothing")
+	}
+	if err := json.Unmarshal([]byte(`{"alert": "*_TEC_* & !IO_*"}`), &config); err != nil {
+		t.Fatal(err)
+	}
+	if !config.Alert.MatchString("DB_TEC_Timeout") || config.Alert.MatchString("IO_TEC_Read") {
+		t.Errorf("Unexpected matches of %s", config.Alert)
+	}
+	if b, _ := json.Marshal(config); string(b) != `{"alert":"*_TEC_* \u0026 !IO_*"}` {
+		t.Errorf("Unexpected JSON %s", b)
+	}
+	err := json.Unmarshal([]byte(`{"alert": "*_TEC_* &"}`), &config)
+	if !errors.Match(err, errors.ErrCodeMatcherSyntax) {
+		t.Errorf("Expect code %s, got %v", errors.ErrCodeMatcherSyntax, err)
+	}
+}
+
+func FuzzParseMatcher(f *testing.F) {
+	for _, expr := range []string{"AD_* | NF_BIS_* & !NF_BIS_Cache", "!(A|B)&C", "((", "A B", "*?*", ""} {
+		f.Add(expr, "NF_BIS_Order")
+	}
+	f.Fuzz(func(t *testing.T, expr, code string) {
+		m, err := errors.ParseMatcher(expr)
+		if err != nil {
+			if m != nil {
+				t.Fatalf("ParseMatcher(%q) returned both a matcher and %v", expr, err)
+			}
+			if code, _ := errors.GetCode(err); code != errors.ErrCodeMatcherSyntax {
+				t.Fatalf("ParseMatcher(%q) returned an error with code %q", expr, code)
+			}
+			return
+		}
+		// Grouping and double negation must not change the result.
+		for _, equivalent := range []string{"(" + expr + ")", "!!(" + expr + ")", " " + expr + " "} {
+			m2, err := errors.ParseMatcher(equivalent)
+			if err != nil {
+				t.Fatalf("ParseMatcher(%q) succeeded, but ParseMatcher(%q): %v", expr, equivalent, err)
+			}
+			if m.MatchString(code) != m2.MatchString(code) {
+				t.Fatalf("%q and %q disagree on %q", expr, equivalent, code)
+			}
+		}
+	})
+}
@@ -0,0 +1,184 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"encoding/json"
+	"expvar"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Recorder records the codes of the errors created by ErrCode, ErrCodef,
+// TraceableErrCode, TraceableErrCodef, WithErrCode, WithErrCodef, Wrap, Wrapf,
+// WrapNodup and WrapNodupf. ConstError values are constants and are not
+// recorded.
+//
+// Record is called synchronously by the constructors, it must be safe for
+// concurrent use and fast.
+type Recorder interface {
+	Record(code string)
+}
+
+var (
+	recorderMu     sync.Mutex
+	removeRecorder func()
+)
+
+// SetRecorder installs r to record the codes of created errors, replacing the
+// previous one. Recording is disabled by default and when r is nil.
+// The Recorder is called by a Hook.
+func SetRecorder(r Recorder) {
+	recorderMu.Lock()
+	defer recorderMu.Unlock()
+	if removeRecorder != nil {
+		removeRecorder()
+		removeRecorder = nil
+	}
+	if r != nil {
+		removeRecorder = AddHook(func(e Event) {
+			if e.Code != "" {
+				r.Record(e.Code)
+			}
+		})
+	}
+}
+
+// CodeStat is the number of errors created with a code and when they were
+// first and last seen.
+type CodeStat struct {
+	Count     int64     `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Family returns the family of code, the part before its first '_' or '-',
+// such as "NF" for "NF_BIS_Order".
+func Family(code string) string {
+	if i := strings.IndexAny(code, "_-"); i >= 0 {
+		return code[:i]
+	}
+	return code
+}
+
+// CodeMetrics is a Recorder counting errors by code and family.
+// It implements expvar.Var, so it can be published with expvar.Publish.
+type CodeMetrics struct {
+	// Family returns the family of a code. The default is the Family function.
+	Family func(code string) string
+	// Now returns the current time. The default is time.Now.
+	Now func() time.Time
+
+	mu       sync.Mutex
+	codes    map[string]*CodeStat
+	families map[string]int64
+}
+
+// NewCodeMetrics returns empty metrics.
+func NewCodeMetrics() *CodeMetrics {
+	return &CodeMetrics{
+		Family:   Family,
+		Now:      time.Now,
+		codes:    make(map[string]*CodeStat),
+		families: make(map[string]int64),
+	}
+}
+
+// Record counts an error created with code.
+func (m *CodeMetrics) Record(code string) {
+	now := m.Now()
+	family := m.Family(code)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stat, ok := m.codes[code]
+	if !ok {
+		stat = &CodeStat{FirstSeen: now}
+		m.codes[code] = stat
+	}
+	stat.Count++
+	stat.LastSeen = now
+	m.families[family]++
+}
+
+// Codes returns a copy of the statistics by code.
+func (m *CodeMetrics) Codes() map[string]CodeStat {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	codes := make(map[string]CodeStat, len(m.codes))
+	for code, stat := range m.codes {
+		codes[code] = *stat
+	}
+	return codes
+}
+
+// Families returns a copy of the counts by family.
+func (m *CodeMetrics) Families() map[string]int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	families := make(map[string]int64, len(m.families))
+	for family, count := range m.families {
+		families[family] = count
+	}
+	return families
+}
+
+// Reset clears the metrics.
+func (m *CodeMetrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.codes = make(map[string]*CodeStat)
+	m.families = make(map[string]int64)
+}
+
+// String returns the metrics in JSON, as required by expvar.Var.
+func (m *CodeMetrics) String() string {
+	b, err := json.Marshal(struct {
+		Codes    map[string]CodeStat `json:"codes"`
+		Families map[string]int64    `json:"families"`
+	}{m.Codes(), m.Families()})
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
+}
+
+// Top returns the n codes counted the most, in decreasing order.
+func (m *CodeMetrics) Top(n int) []string {
+	codes := m.Codes()
+	top := make([]string, 0, len(codes))
+	for code := range codes {
+		top = append(top, code)
+	}
+	sort.Slice(top, func(i, j int) bool {
+		ci, cj := codes[top[i]].Count, codes[top[j]].Count
+		return ci > cj || ci == cj && top[i] < top[j]
+	})
+	if n >= 0 && n < len(top) {
+		top = top[:n]
+	}
+	return top
+}
+
+// EnableMetrics installs new metrics as the Recorder and publishes them
+// with expvar under name, such as "errors". It panics if name is already
+// published, like expvar.Publish.
+func EnableMetrics(name string) *CodeMetrics {
+	m := NewCodeMetrics()
+	expvar.Publish(name, m)
+	SetRecorder(m)
+	return m
+}
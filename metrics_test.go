@@ -0,0 +1,77 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nextf/errors"
+)
+
+func TestCodeMetrics(t *testing.T) {
+	m := errors.EnableMetrics("errors_test")
+	defer errors.SetRecorder(nil)
+	now := time.Unix(100, 0)
+	m.Now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	root := errors.ErrCodef("NF_BIS_Order", "Not found order %d", 1)
+	errors.Wrap(root, "NF_BIS_Order", "Query failed")
+	errors.WrapNodupf(root, "AD_TEC_Db", "Access %s", "denied")
+	errors.TraceableErrCode("AD-Cache", "Access denied")
+	errors.WithErrCode(nil, "AD_TEC_Db", "Not recorded")
+	errors.New("[NF_BIS_Order] Not recorded")
+
+	codes := m.Codes()
+	if want := (errors.CodeStat{Count: 2, FirstSeen: time.Unix(101, 0), LastSeen: time.Unix(102, 0)}); codes["NF_BIS_Order"] != want {
+		t.Errorf("Expect %v, got %v", want, codes["NF_BIS_Order"])
+	}
+	if len(codes) != 3 || codes["AD-Cache"].Count != 1 {
+		t.Errorf("Unexpected codes %v", codes)
+	}
+	if families := m.Families(); !reflect.DeepEqual(families, map[string]int64{"NF": 2, "AD": 2}) {
+		t.Errorf("Expect %v, got %v", map[string]int64{"NF": 2, "AD": 2}, families)
+	}
+	if top := m.Top(1); !reflect.DeepEqual(top, []string{"NF_BIS_Order"}) {
+		t.Errorf("Expect %v, got %v", []string{"NF_BIS_Order"}, top)
+	}
+
+	var exported struct {
+		Codes    map[string]errors.CodeStat
+		Families map[string]int64
+	}
+	if err := json.Unmarshal([]byte(expvar.Get("errors_test").String()), &exported); err != nil {
+		t.Fatal(err)
+	}
+	if exported.Codes["AD_TEC_Db"].Count != 1 || exported.Families["AD"] != 2 {
+		t.Errorf("Unexpected metrics %+v", exported)
+	}
+
+	errors.SetRecorder(nil)
+	errors.ErrCode("NF_BIS_Order", "Not recorded")
+	if count := m.Codes()["NF_BIS_Order"].Count; count != 2 {
+		t.Errorf("Expect %d, got %d", 2, count)
+	}
+	m.Reset()
+	if len(m.Codes()) != 0 || len(m.Families()) != 0 {
+		t.Errorf("Expect no metrics after Reset")
+	}
+}
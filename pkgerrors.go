@@ -0,0 +1,167 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/nextf/errors/stack"
+)
+
+// Errors created by github.com/pkg/errors and the libraries built on it
+// record their call stack in a method StackTrace() errors.StackTrace, where
+// errors.StackTrace is a []errors.Frame and errors.Frame is a uintptr, and
+// refer to the wrapped error with a method Cause() error. They are recognized
+// by their method sets, without depending on the package.
+
+// next returns the error wrapped by err, obtained by calling its Unwrap
+// method, or its Cause method if it has no Unwrap method.
+func next(err error) error {
+	switch x := err.(type) {
+	case interface{ Unwrap() error }:
+		return x.Unwrap()
+	case interface{ Cause() error }:
+		return x.Cause()
+	}
+	return nil
+}
+
+// trimCause returns message without the message of cause, which errors of
+// github.com/pkg/errors and fmt.Errorf("...: %w") repeat.
+func trimCause(message string, cause error) string {
+	if cause == nil {
+		return message
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(message, cause.Error()), ": ")
+}
+
+// hasStackTrace reports whether err itself has call stack information.
+func hasStackTrace(err error) bool {
+	if _, ok := err.(interface{ StackTrace() []stack.Frame }); ok {
+		return true
+	}
+	return foreignStackTraceMethod(err) >= 0
+}
+
+// foreignCallStack returns the call stack of an error from github.com/pkg/errors.
+func foreignCallStack(err error) (stack.CallStack, bool) {
+	index := foreignStackTraceMethod(err)
+	if index < 0 {
+		return nil, false
+	}
+	frames := reflect.ValueOf(err).Method(index).Call(nil)[0]
+	pcs := make(stack.CallStack, frames.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(frames.Index(i).Uint())
+	}
+	return pcs, true
+}
+
+// foreignStackTraceMethod returns the index of the method StackTrace of an
+// error from github.com/pkg/errors, or -1 if err is not one.
+func foreignStackTraceMethod(err error) int {
+	switch err.(type) {
+	case chainFormatter, ConstError:
+		// The errors of this package, which are not foreign.
+		return -1
+	}
+	return stackTraceMethod(reflect.TypeOf(err))
+}
+
+// stackTraceMethods caches the result of stackTraceMethod by type.
+var stackTraceMethods sync.Map
+
+// stackTraceMethod returns the index of the method StackTrace of t returning
+// a slice of uintptr, or -1 if t has none.
+func stackTraceMethod(t reflect.Type) int {
+	if t == nil {
+		return -1
+	}
+	if index, ok := stackTraceMethods.Load(t); ok {
+		return index.(int)
+	}
+	index := -1
+	if m, ok := t.MethodByName("StackTrace"); ok {
+		mt := m.Type
+		// The receiver is the first parameter of a method of a type.
+		if mt.NumIn() == 1 && mt.NumOut() == 1 && mt.Out(0).Kind() == reflect.Slice && mt.Out(0).Elem().Kind() == reflect.Uintptr {
+			index = m.Index
+		}
+	}
+	stackTraceMethods.Store(t, index)
+	return index
+}
+
+// foreignError formats an error from github.com/pkg/errors like the errors
+// of this package, so that its call stack and causes are not hidden by %+v.
+type foreignError struct {
+	err   error
+	stack stack.CallStack
+	cause error
+}
+
+// formattable returns the value to format in place of err.
+func formattable(err error) interface{} {
+	switch err.(type) {
+	case chainFormatter, ConstError:
+		return err
+	}
+	cs, hasStack := foreignCallStack(err)
+	_, hasCause := err.(interface{ Cause() error })
+	if !hasStack && !hasCause {
+		return err
+	}
+	return &foreignError{err, cs, next(err)}
+}
+
+func (c *foreignError) Format(s fmt.State, verb rune) {
+	format(s, verb, c.err, c)
+}
+
+func (c *foreignError) formatChain(s fmt.State, verb rune, ch chain) {
+	switch verb {
+	case 'v':
+		if !s.Flag('+') {
+			io.WriteString(s, c.err.Error())
+			break
+		}
+		width, hasWidth := s.Width()
+		message := trimCause(c.err.Error(), c.cause)
+		separator := ""
+		if message != "" {
+			io.WriteString(s, message)
+			separator = "\nCaused by: "
+		}
+		if c.stack != nil && !s.Flag('-') && !ch.skipStack(c.cause) {
+			formatCallStack := "%s@callstack\n%+v"
+			if hasWidth {
+				formatCallStack = fmt.Sprintf("%%s@callstack\n%%+%dv", width)
+			}
+			fmt.Fprintf(s, formatCallStack, separator, c.stack)
+			separator = "\nCaused by: "
+		}
+		if c.cause != nil {
+			fmt.Fprintf(s, "%s"+causeFormat(s, verb), separator, ch.link(c.cause))
+		}
+	case 's':
+		io.WriteString(s, c.err.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", c.err.Error())
+	}
+}
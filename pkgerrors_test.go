@@ -0,0 +1,148 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors_test
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/nextf/errors"
+)
+
+// The types below mimic the errors of github.com/pkg/errors.
+
+type pkgFrame uintptr
+
+type pkgStackTrace []pkgFrame
+
+type pkgFundamental struct {
+	msg   string
+	stack []uintptr
+}
+
+func newPkgFundamental(msg string) error {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	return &pkgFundamental{msg, pcs[:n]}
+}
+
+func (f *pkgFundamental) Error() string { return f.msg }
+
+func (f *pkgFundamental) StackTrace() pkgStackTrace {
+	st := make(pkgStackTrace, len(f.stack))
+	for i, pc := range f.stack {
+		st[i] = pkgFrame(pc)
+	}
+	return st
+}
+
+type pkgWithMessage struct {
+	cause error
+	msg   string
+}
+
+func (w *pkgWithMessage) Error() string { return w.msg + ": " + w.cause.Error() }
+
+func (w *pkgWithMessage) Cause() error { return w.cause }
+
+func TestPkgErrorsStackTrace(t *testing.T) {
+	err := newPkgFundamental("Root level")
+	if !errors.HasStackTrace(err) {
+		t.Errorf("It was expected that there would has StackTrace in the `err`, but it wasn't.")
+	}
+	if traced := errors.TraceNodup(err); traced != err {
+		t.Errorf("Expect %v, got %v", err, traced)
+	}
+	wrapped := &pkgWithMessage{err, "Level 1"}
+	if !errors.HasStackTrace(wrapped) {
+		t.Errorf("It was expected that there would has StackTrace in the `err`, but it wasn't.")
+	}
+	if traced := errors.TraceNodup(wrapped); traced != wrapped {
+		t.Errorf("Expect %v, got %v", wrapped, traced)
+	}
+}
+
+func TestPkgErrorsCause(t *testing.T) {
+	root := errors.ErrCode("NOT_FOUND", "Not found page")
+	err := errors.WithErrCode(&pkgWithMessage{root, "Level 1"}, "L2", "Level 2")
+	if !errors.Match(err, "NOT_FOUND") {
+		t.Errorf("Expect %v, got %v", "code=NOT_FOUND", "[NotMatch]")
+	}
+	if cause := errors.Cause(err); cause != root {
+		t.Errorf("Expect %v, got %v", root, cause)
+	}
+	if code, ok := errors.GetCode(&pkgWithMessage{root, "Level 1"}); !ok || code != "NOT_FOUND" {
+		t.Errorf("Expect code=%s, got [%s]", "NOT_FOUND", code)
+	}
+}
+
+func TestPkgErrorsFormat(t *testing.T) {
+	err := errors.WithErrCode(&pkgWithMessage{newPkgFundamental("Root level"), "Level 1"}, "L2", "Level 2")
+	text := fmt.Sprintf("%+2v", err)
+	prefix := "[L2] Level 2\nCaused by: Level 1\nCaused by: Root level\nCaused by: @callstack\n" +
+		"\x20\x20\x20\x20github.com/nextf/errors_test.TestPkgErrorsFormat(pkgerrors_test.go:94)\n"
+	if !strings.HasPrefix(text, prefix) {
+		t.Errorf("Expected message was not obtained\n%s", text)
+	}
+	if !strings.Contains(text, "...(more:") {
+		t.Errorf("Expected the width to limit the call stack\n%s", text)
+	}
+	if text := fmt.Sprintf("%+v", errors.Trace(&pkgWithMessage{errors.New("Root level"), "Level 1"})); !strings.HasSuffix(text, "\nCaused by: Level 1\nCaused by: Root level") {
+		t.Errorf("Expected message was not obtained\n%s", text)
+	}
+}
+
+func TestStackTrace(t *testing.T) {
+	err := newPkgFundamental("Root level")
+	frames, ok := errors.StackTrace(err)
+	if !ok || len(frames) == 0 || !strings.HasPrefix(frames[0].Describe(), "github.com/nextf/errors_test.TestStackTrace(") {
+		t.Errorf("Unexpected call stack %v", frames)
+	}
+	if frames, ok := errors.StackTrace(errors.Trace(err)); !ok || len(frames) == 0 {
+		t.Errorf("Unexpected call stack %v", frames)
+	}
+	if _, ok := errors.StackTrace(&pkgWithMessage{err, "Level 1"}); ok {
+		t.Errorf("Expect no call stack for the wrapping error")
+	}
+}
+
+func TestPkgErrorsFormatFlags(t *testing.T) {
+	err := errors.AddSuppressed(&pkgWithMessage{errors.ErrCode("IN", "inner"), "outer"}, errors.New("close failed"))
+	for _, format := range []string{"%+-5v", "%+5v", "%+-v"} {
+		if s := fmt.Sprintf(format, err); !strings.HasPrefix(s, "outer\nCaused by: [IN] inner") {
+			t.Errorf("%s: Expected message was not obtained\n%s", format, s)
+		}
+	}
+}
+
+func BenchmarkHasStackTrace(b *testing.B) {
+	var err error = errors.New("Root")
+	for i := 0; i < 5; i++ {
+		err = errors.WithErrCode(err, "L", "Level")
+	}
+	b.Run("without", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			errors.HasStackTrace(err)
+		}
+	})
+	foreign := errors.WithErrCode(&pkgWithMessage{newPkgFundamental("Root"), "read"}, "L", "Level")
+	b.Run("pkg/errors", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			errors.HasStackTrace(foreign)
+		}
+	})
+}
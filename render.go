@@ -0,0 +1,362 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+
+	"github.com/nextf/errors/stack"
+)
+
+// Formatter renders an error and its chain.
+//
+// A Formatter set by SetDefaultFormatter is used by %+v, so it must not
+// format err with %+v itself.
+type Formatter interface {
+	Render(w io.Writer, err error) error
+}
+
+// Render renders err to w with f, or with the default formatter if f is nil.
+// If err is nil, Render writes nothing.
+func Render(w io.Writer, err error, f Formatter) error {
+	if err == nil {
+		return nil
+	}
+	if f == nil {
+		f = DefaultFormatter()
+	}
+	return f.Render(w, err)
+}
+
+var defaultFormatter atomic.Pointer[Formatter]
+
+// SetDefaultFormatter sets the formatter used by %+v and Render. The width,
+// precision and flags of %+v only apply to the built-in formatter FormatOptions.
+// If f is nil, the built-in formatter is restored.
+func SetDefaultFormatter(f Formatter) {
+	if f == nil {
+		defaultFormatter.Store(nil)
+		return
+	}
+	defaultFormatter.Store(&f)
+}
+
+// DefaultFormatter returns the formatter used by %+v and Render.
+func DefaultFormatter() Formatter {
+	if f := defaultFormatter.Load(); f != nil {
+		return *f
+	}
+	return FormatOptions{}
+}
+
+// format formats err, the outermost error of a chain, for the Format method
+// of x, using the default formatter for %+v.
+func format(s fmt.State, verb rune, err error, x chainFormatter) {
+	if verb == 'v' && s.Flag('+') {
+		if f := defaultFormatter.Load(); f != nil {
+			(*f).Render(s, err)
+			return
+		}
+	}
+	x.formatChain(s, verb, newChain(s, err))
+}
+
+// Render renders err like %+v with the options.
+func (o FormatOptions) Render(w io.Writer, err error) error {
+	_, werr := io.WriteString(w, o.Sprint(err))
+	return werr
+}
+
+// SingleLineFormatter renders a chain on a single line, as in
+//
+//	API_Order: Order failed: NF_BIS_Order: Not found: EOF
+//
+// The errors wrapped together, such as by errors.Join, are listed in brackets
+// and separated by semicolons. Call stacks and suppressed errors are omitted.
+type SingleLineFormatter struct{}
+
+func (SingleLineFormatter) Render(w io.Writer, err error) error {
+	_, werr := io.WriteString(w, singleLine(chainNodes(err)))
+	return werr
+}
+
+func singleLine(nodes []*node) string {
+	lines := make([]string, len(nodes))
+	for i, n := range nodes {
+		lines[i] = n.title(": ")
+		if len(n.causes) > 0 {
+			lines[i] += ": " + singleLine(n.causes)
+		}
+	}
+	if len(lines) == 1 {
+		return lines[0]
+	}
+	return "[" + strings.Join(lines, "; ") + "]"
+}
+
+// JavaFormatter renders a chain like a Java stack trace, as in
+//
+//	API_Order: Order failed
+//		at main.order(main.go:12)
+//	Caused by: NF_BIS_Order: Not found
+//		at main.find(main.go:20)
+//		Suppressed: IO_TEC_Close: close failed
+//
+// Only the first of the errors wrapped together, such as by errors.Join, is
+// a cause, the others are rendered as suppressed errors.
+type JavaFormatter struct{}
+
+func (JavaFormatter) Render(w io.Writer, err error) error {
+	var lines []string
+	java(&lines, chainNodes(err), "", "")
+	_, werr := io.WriteString(w, strings.Join(lines, "\n"))
+	return werr
+}
+
+func java(lines *[]string, nodes []*node, label, indent string) {
+	if len(nodes) == 0 {
+		return
+	}
+	n := nodes[0]
+	*lines = append(*lines, indent+label+n.title(": "))
+	for _, frame := range n.frames {
+		*lines = append(*lines, indent+"\tat "+frame.Describe())
+	}
+	for _, s := range append(n.suppressed, nodes[1:]...) {
+		java(lines, []*node{s}, "Suppressed: ", indent+"\t")
+	}
+	java(lines, n.causes, "Caused by: ", indent)
+}
+
+// PythonFormatter renders a chain like a Python traceback, root cause first,
+// as in
+//
+//	Traceback (most recent call last):
+//	  File "/src/main.go", line 20, in main.find
+//	NF_BIS_Order: Not found
+//
+//	The above exception was the direct cause of the following exception:
+//
+//	Traceback (most recent call last):
+//	  File "/src/main.go", line 12, in main.order
+//	API_Order: Order failed
+//
+// Only the first of the errors wrapped together, such as by errors.Join, is
+// rendered. Suppressed errors are omitted.
+type PythonFormatter struct{}
+
+func (PythonFormatter) Render(w io.Writer, err error) error {
+	var nodes []*node
+	for ns := chainNodes(err); len(ns) > 0; ns = ns[0].causes {
+		nodes = append(nodes, ns[0])
+	}
+	var lines []string
+	for i := len(nodes) - 1; i >= 0; i-- {
+		n := nodes[i]
+		if i < len(nodes)-1 {
+			lines = append(lines, "", "The above exception was the direct cause of the following exception:", "")
+		}
+		if len(n.frames) > 0 {
+			lines = append(lines, "Traceback (most recent call last):")
+			for j := len(n.frames) - 1; j >= 0; j-- {
+				f := n.frames[j]
+				lines = append(lines, fmt.Sprintf("  File %q, line %d, in %s", f.File, f.Line, f.Function))
+			}
+		}
+		lines = append(lines, n.title(": "))
+	}
+	_, werr := io.WriteString(w, strings.Join(lines, "\n"))
+	return werr
+}
+
+// TreeFormatter renders a chain as a tree, which shows the structure of
+// errors wrapping several errors, as in
+//
+//	[API_Order] Order failed
+//	├── [NF_BIS_Order] Not found
+//	└── Suppressed: [IO_TEC_Close] close failed
+//	    └── file already closed
+//
+// Call stacks are omitted.
+type TreeFormatter struct{}
+
+func (TreeFormatter) Render(w io.Writer, err error) error {
+	var lines []string
+	for _, n := range chainNodes(err) {
+		tree(&lines, n, "", "")
+	}
+	_, werr := io.WriteString(w, strings.Join(lines, "\n"))
+	return werr
+}
+
+func tree(lines *[]string, n *node, head, indent string) {
+	*lines = append(*lines, head+n.label+n.title(" "))
+	children := n.causes
+	for _, s := range n.suppressed {
+		children = append(children, &node{label: "Suppressed: ", code: s.code, message: s.message,
+			causes: s.causes, suppressed: s.suppressed, truncated: s.truncated})
+	}
+	for i, child := range children {
+		if i == len(children)-1 {
+			tree(lines, child, indent+"└── ", indent+"    ")
+		} else {
+			tree(lines, child, indent+"├── ", indent+"│   ")
+		}
+	}
+}
+
+// node is an error of a chain as rendered by the formatters, with the call
+// stack recorded with it and the errors it wraps.
+type node struct {
+	label      string
+	code       string
+	message    string
+	frames     []stack.Frame
+	causes     []*node
+	suppressed []*node
+	truncated  bool
+}
+
+// title returns the code and the message of n separated by sep, such as
+// "NF_BIS_Order: Not found".
+func (n *node) title(sep string) string {
+	switch {
+	case n.truncated:
+		return "... (chain truncated)"
+	case sep == " " && n.code != "":
+		return "[" + n.code + "] " + n.message
+	case n.code != "" && n.message != "":
+		return n.code + sep + n.message
+	}
+	return n.code + n.message
+}
+
+// chainNodes returns the nodes of err's chain.
+func chainNodes(err error) []*node {
+	return nodesOf(err, nil)
+}
+
+// nodesOf returns the nodes of the chain of err, the cause of the errors of
+// path. Call stacks recorded by Trace and Wrap are attached to the error they
+// were recorded with, and the errors adding neither a code nor a message are
+// not nodes themselves.
+func nodesOf(err error, path []error) []*node {
+	var (
+		roots      []*node
+		cur        *node
+		frames     []stack.Frame
+		suppressed []*node
+	)
+	add := func(n *node) {
+		if cur == nil {
+			roots = append(roots, n)
+		} else {
+			cur.causes = append(cur.causes, n)
+		}
+		cur = n
+		if frames != nil && n.frames == nil {
+			n.frames, frames = frames, nil
+		}
+		n.suppressed, suppressed = append(n.suppressed, suppressed...), nil
+	}
+	for err != nil {
+		if len(path) >= maxChainDepth() || (chain{path: path}).contains(err) {
+			add(&node{truncated: true})
+			break
+		}
+		path = append(path[:len(path):len(path)], err)
+		switch x := err.(type) {
+		case *errorStack:
+			if cur != nil && cur.frames == nil {
+				cur.frames = stackFrames(x.stack)
+			} else if frames == nil {
+				frames = stackFrames(x.stack)
+			}
+		case *withSuppressed:
+			// The suppressed errors belong to the primary error, the cause.
+			for _, s := range x.suppressed {
+				suppressed = append(suppressed, nodesOf(s, path)...)
+			}
+		case *withClass:
+		case interface{ Unwrap() []error }:
+			errs := x.Unwrap()
+			n := &node{message: err.Error()}
+			if n.message == joined(errs) {
+				n.message = ""
+			}
+			if x, ok := err.(interface{ Code() string }); ok {
+				n.code = x.Code()
+			}
+			if n.code != "" || n.message != "" {
+				add(n)
+			}
+			for _, e := range errs {
+				if e == nil {
+					continue
+				}
+				children := nodesOf(e, path)
+				if cur == nil {
+					roots = append(roots, children...)
+				} else {
+					cur.causes = append(cur.causes, children...)
+				}
+			}
+			return roots
+		default:
+			n := &node{message: ownMessage(err)}
+			if x, ok := err.(interface{ Code() string }); ok {
+				n.code = x.Code()
+			}
+			if cs, ok := foreignCallStack(err); ok {
+				n.frames = stackFrames(cs)
+			}
+			add(n)
+		}
+		err = next(err)
+	}
+	return roots
+}
+
+// ownMessage returns the message err adds to its cause.
+func ownMessage(err error) string {
+	if _, ok := err.(chainFormatter); ok {
+		return err.Error()
+	}
+	return trimCause(err.Error(), next(err))
+}
+
+// joined returns the message of errors.Join(errs...).
+func joined(errs []error) string {
+	var messages []string
+	for _, err := range errs {
+		if err != nil {
+			messages = append(messages, err.Error())
+		}
+	}
+	return strings.Join(messages, "\n")
+}
+
+func stackFrames(cs stack.CallStack) []stack.Frame {
+	var frames []stack.Frame
+	for _, frame := range cs.StackTrace() {
+		if frame.Function != "" {
+			frames = append(frames, frame)
+		}
+	}
+	return frames
+}
@@ -0,0 +1,134 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors_test
+
+import (
+	"bytes"
+	stderr "errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/nextf/errors"
+)
+
+func renderString(err error, f errors.Formatter) string {
+	var buf bytes.Buffer
+	if werr := errors.Render(&buf, err, f); werr != nil {
+		return werr.Error()
+	}
+	return buf.String()
+}
+
+func TestRender(t *testing.T) {
+	closeErr := errors.WithErrCode(fmt.Errorf("close: %w", os.ErrClosed), "IO_TEC_Close", "close failed")
+	inner := errors.AddSuppressed(errors.WithErrCode(io.EOF, "NF_BIS_Order", "Not found"), closeErr)
+	err := errors.WithErrCode(inner, "API_Order", "Order failed")
+	joined := errors.WithErrCode(stderr.Join(errors.ErrCode("A", "a"), errors.Annotate(errors.ErrCode("B", "b"), "annotated")), "J", "joined")
+	tests := []struct {
+		f          errors.Formatter
+		err, multi string
+	}{
+		{
+			errors.FormatOptions{},
+			"[API_Order] Order failed\nCaused by: [NF_BIS_Order] Not found\nCaused by: EOF\nSuppressed: [IO_TEC_Close] close failed\n    Caused by: close: file already closed",
+			"[J] joined\nCaused by: a\nannotated",
+		},
+		{
+			errors.SingleLineFormatter{},
+			"API_Order: Order failed: NF_BIS_Order: Not found: EOF",
+			"J: joined: [A: a; annotated: B: b]",
+		},
+		{
+			errors.JavaFormatter{},
+			"API_Order: Order failed\nCaused by: NF_BIS_Order: Not found\n\tSuppressed: IO_TEC_Close: close failed\n\tCaused by: close\n\tCaused by: file already closed\nCaused by: EOF",
+			"J: joined\nCaused by: A: a\n\tSuppressed: annotated\n\tCaused by: B: b",
+		},
+		{
+			errors.PythonFormatter{},
+			"EOF\n\nThe above exception was the direct cause of the following exception:\n\nNF_BIS_Order: Not found\n\nThe above exception was the direct cause of the following exception:\n\nAPI_Order: Order failed",
+			"A: a\n\nThe above exception was the direct cause of the following exception:\n\nJ: joined",
+		},
+		{
+			errors.TreeFormatter{},
+			"[API_Order] Order failed\n└── [NF_BIS_Order] Not found\n    ├── EOF\n    └── Suppressed: [IO_TEC_Close] close failed\n        └── close\n            └── file already closed",
+			"[J] joined\n├── [A] a\n└── annotated\n    └── [B] b",
+		},
+	}
+	for _, tt := range tests {
+		if s := renderString(err, tt.f); s != tt.err {
+			t.Errorf("%T: Expect\n%s\ngot\n%s", tt.f, tt.err, s)
+		}
+		if s := renderString(joined, tt.f); s != tt.multi {
+			t.Errorf("%T: Expect\n%s\ngot\n%s", tt.f, tt.multi, s)
+		}
+	}
+	if s := renderString(stderr.Join(errors.ErrCode("A", "a"), io.EOF), errors.TreeFormatter{}); s != "[A] a\nEOF" {
+		t.Errorf("Expect the joined errors as roots, got\n%s", s)
+	}
+	if s := renderString(nil, errors.JavaFormatter{}); s != "" {
+		t.Errorf("Expect nothing rendered for nil, got %q", s)
+	}
+}
+
+func TestRenderStack(t *testing.T) {
+	err := errors.Wrap(errors.Trace(io.EOF), "API_Order", "Order failed")
+	java := renderString(err, errors.JavaFormatter{})
+	want := "API_Order: Order failed\n\tat github.com/nextf/errors_test.TestRenderStack(render_test.go:89)\n"
+	if !strings.HasPrefix(java, want) || !strings.Contains(java, "\nCaused by: EOF\n\tat github.com/nextf/errors_test.TestRenderStack(render_test.go:89)") {
+		t.Errorf("Expect prefix %q, got\n%s", want, java)
+	}
+	python := renderString(err, errors.PythonFormatter{})
+	if !strings.HasPrefix(python, "Traceback (most recent call last):\n") || !strings.Contains(python, "render_test.go\", line 89, in github.com/nextf/errors_test.TestRenderStack\nEOF\n") {
+		t.Errorf("Unexpected traceback\n%s", python)
+	}
+	if s := renderString(err, errors.FormatOptions{StackDepth: 1}); s != fmt.Sprintf("%+1v", err) {
+		t.Errorf("Expect %q, got %q", fmt.Sprintf("%+1v", err), s)
+	}
+}
+
+func TestRenderCycle(t *testing.T) {
+	self := &loopError{code: "SELF"}
+	self.next = self
+	err := errors.WithErrCode(self, "TOP", "Top")
+	if s := renderString(err, errors.SingleLineFormatter{}); s != "TOP: Top: SELF: ... (chain truncated)" {
+		t.Errorf("Unexpected rendering %q", s)
+	}
+}
+
+func TestSetDefaultFormatter(t *testing.T) {
+	defer errors.SetDefaultFormatter(nil)
+	err := errors.Wrap(io.EOF, "API_Order", "Order failed")
+	errors.SetDefaultFormatter(errors.SingleLineFormatter{})
+	if s := fmt.Sprintf("%+v", err); s != "API_Order: Order failed: EOF" {
+		t.Errorf("Expect %q, got %q", "API_Order: Order failed: EOF", s)
+	}
+	if s := fmt.Sprintf("%v", err); s != "[API_Order] Order failed" {
+		t.Errorf("Expect %q, got %q", "[API_Order] Order failed", s)
+	}
+	if s := renderString(err, nil); s != "API_Order: Order failed: EOF" {
+		t.Errorf("Expect the default formatter, got %q", s)
+	}
+	errors.SetDefaultFormatter(errors.FormatOptions{InnermostStack: true})
+	if n := strings.Count(fmt.Sprintf("%+v", errors.Wrap(err, "API_Retry", "Retry failed")), "@callstack"); n != 1 {
+		t.Errorf("Expect %d call stack, got %d", 1, n)
+	}
+	errors.SetDefaultFormatter(nil)
+	if _, ok := errors.DefaultFormatter().(errors.FormatOptions); !ok {
+		t.Errorf("Expect the built-in formatter to be restored")
+	}
+}
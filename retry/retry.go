@@ -0,0 +1,131 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package retry retries operations failing with errors that are classified
+// as retryable by github.com/nextf/errors.
+package retry
+
+import (
+	"context"
+	stderr "errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/nextf/errors"
+)
+
+// ErrCodeCanceled is the code of the error returned by Do if ctx is done
+// while waiting for a retry.
+const ErrCodeCanceled = "RETRY_Canceled"
+
+// Policy configures the retries. A zero field takes its value from
+// DefaultPolicy.
+type Policy struct {
+	// MaxAttempts is the maximum number of calls, including the first one.
+	MaxAttempts int
+	// InitialDelay is the delay before the first retry.
+	InitialDelay time.Duration
+	// MaxDelay caps the delay computed by the exponential backoff.
+	// A retry-after duration of a throttled error is honored even if it
+	// exceeds MaxDelay.
+	MaxDelay time.Duration
+	// Multiplier is the factor applied to the delay after each retry.
+	Multiplier float64
+	// Jitter is the fraction of the delay, between 0 and 1, that is
+	// randomized to spread the retries of concurrent callers.
+	// A negative value disables the jitter.
+	Jitter float64
+	// Retryable reports whether an error is worth retrying.
+	// By default, all errors are retried except permanent ones.
+	Retryable func(err error) bool
+}
+
+// DefaultPolicy is the policy used by Do for zero fields.
+var DefaultPolicy = Policy{
+	MaxAttempts:  3,
+	InitialDelay: 100 * time.Millisecond,
+	MaxDelay:     10 * time.Second,
+	Multiplier:   2,
+	Jitter:       0.2,
+	Retryable:    func(err error) bool { return !errors.IsPermanent(err) },
+}
+
+func (p Policy) withDefaults() Policy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultPolicy.MaxAttempts
+	}
+	if p.InitialDelay <= 0 {
+		p.InitialDelay = DefaultPolicy.InitialDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = DefaultPolicy.MaxDelay
+	}
+	if p.Multiplier <= 0 {
+		p.Multiplier = DefaultPolicy.Multiplier
+	}
+	if p.Jitter == 0 {
+		p.Jitter = DefaultPolicy.Jitter
+	}
+	if p.Jitter < 0 {
+		p.Jitter = 0
+	} else if p.Jitter > 1 {
+		p.Jitter = 1
+	}
+	if p.Retryable == nil {
+		p.Retryable = DefaultPolicy.Retryable
+	}
+	return p
+}
+
+// Delay returns the delay before the retry following the given attempt,
+// counted from 1, that failed with err.
+func (p Policy) Delay(attempt int, err error) time.Duration {
+	p = p.withDefaults()
+	delay := float64(p.InitialDelay)
+	for i := 1; i < attempt && delay < float64(p.MaxDelay); i++ {
+		delay *= p.Multiplier
+	}
+	if delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	delay -= delay * p.Jitter * rand.Float64()
+	if retryAfter, ok := errors.RetryAfter(err); ok && time.Duration(delay) < retryAfter {
+		return retryAfter
+	}
+	return time.Duration(delay)
+}
+
+// Do calls fn until it succeeds, fails with an error that is not retryable,
+// the attempts are exhausted or ctx is done, waiting between the calls
+// according to p. It returns the error of the last call, or nil.
+//
+// If ctx is done while waiting, Do returns an error with code
+// ErrCodeCanceled, whose chain has both the error of the last call and the
+// error of ctx, so that errors.Is(err, context.Canceled) reports true.
+func Do(ctx context.Context, p Policy, fn func(ctx context.Context) error) error {
+	p = p.withDefaults()
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt >= p.MaxAttempts || !p.Retryable(err) {
+			return err
+		}
+		timer := time.NewTimer(p.Delay(attempt, err))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.WithErrCodef(stderr.Join(err, ctx.Err()), ErrCodeCanceled, "retry canceled after %d attempts: %v", attempt, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
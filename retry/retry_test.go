@@ -0,0 +1,120 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package retry_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nextf/errors"
+	"github.com/nextf/errors/retry"
+)
+
+var fast = retry.Policy{
+	MaxAttempts:  4,
+	InitialDelay: time.Millisecond,
+	MaxDelay:     4 * time.Millisecond,
+	Jitter:       -1,
+}
+
+func TestDo(t *testing.T) {
+	calls := 0
+	err := retry.Do(context.Background(), fast, func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.WithClass(errors.ErrCode("TEC_Timeout", "Timeout"), errors.Retryable)
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("Expect %d calls, got %d, %v", 3, calls, err)
+	}
+}
+
+func TestDoExhausted(t *testing.T) {
+	calls := 0
+	err := retry.Do(context.Background(), fast, func(ctx context.Context) error {
+		calls++
+		return errors.ErrCode("TEC_Timeout", "Timeout")
+	})
+	if calls != fast.MaxAttempts || !errors.Match(err, "TEC_Timeout") {
+		t.Errorf("Expect %d calls, got %d, %v", fast.MaxAttempts, calls, err)
+	}
+}
+
+func TestDoPermanent(t *testing.T) {
+	calls := 0
+	err := retry.Do(context.Background(), fast, func(ctx context.Context) error {
+		calls++
+		return errors.WithClass(errors.ErrCode("BIS_Order", "Not found"), errors.Permanent)
+	})
+	if calls != 1 || !errors.Match(err, "BIS_Order") {
+		t.Errorf("Expect %d calls, got %d, %v", 1, calls, err)
+	}
+}
+
+func TestDoRetryAfter(t *testing.T) {
+	calls := 0
+	start := time.Now()
+	err := retry.Do(context.Background(), fast, func(ctx context.Context) error {
+		calls++
+		if calls == 1 {
+			return errors.WithRetryAfter(errors.ErrCode("TEC_Quota", "Too many requests"), 30*time.Millisecond)
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Errorf("Expect %d calls, got %d, %v", 2, calls, err)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("Expect a delay of at least %v, got %v", 30*time.Millisecond, elapsed)
+	}
+}
+
+func TestDoCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	err := retry.Do(ctx, retry.Policy{InitialDelay: time.Hour}, func(ctx context.Context) error {
+		cancel()
+		return errors.ErrCode("TEC_Timeout", "Timeout")
+	})
+	if !errors.Match(err, retry.ErrCodeCanceled) || !errors.Match(err, "TEC_Timeout") {
+		t.Errorf("Expect code=%s, got %v", retry.ErrCodeCanceled, err)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expect %v in the chain, got %v", context.Canceled, err)
+	}
+	if code, _ := errors.GetCode(err); code != retry.ErrCodeCanceled {
+		t.Errorf("Expect code=%s, got %s", retry.ErrCodeCanceled, code)
+	}
+}
+
+func TestDelay(t *testing.T) {
+	p := retry.Policy{InitialDelay: time.Second, MaxDelay: 5 * time.Second, Multiplier: 2, Jitter: -1}
+	for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if got := p.Delay(attempt+1, errors.New("Timeout")); got != want {
+			t.Errorf("attempt %d: Expect %v, got %v", attempt+1, want, got)
+		}
+	}
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := p.Delay(1, nil); got < 500*time.Millisecond || got > time.Second {
+			t.Errorf("Expect a delay between %v and %v, got %v", 500*time.Millisecond, time.Second, got)
+		}
+	}
+	if got := p.Delay(1, errors.WithRetryAfter(errors.New("Slow down"), time.Minute)); got != time.Minute {
+		t.Errorf("Expect %v, got %v", time.Minute, got)
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Class classifies whether an operation that failed with an error is worth
+// retrying.
+type Class int
+
+const (
+	// Unclassified errors have no known retry behaviour.
+	Unclassified Class = iota
+	// Retryable errors are transient, the operation may succeed if retried.
+	Retryable
+	// Permanent errors will not go away by retrying.
+	Permanent
+	// Throttled errors are caused by rate limiting, the operation may succeed
+	// if retried later, possibly after a retry-after duration.
+	Throttled
+)
+
+func (c Class) String() string {
+	switch c {
+	case Retryable:
+		return "retryable"
+	case Permanent:
+		return "permanent"
+	case Throttled:
+		return "throttled"
+	}
+	return "unclassified"
+}
+
+type classRule struct {
+	key        interface{}
+	class      Class
+	retryAfter time.Duration
+}
+
+var classRegistry struct {
+	sync.RWMutex
+	codes map[string]classRule
+	rules []classRule
+}
+
+// RegisterClass classifies the errors whose code matches key, where key is
+// a code or any other key accepted by Match, such as a regexp matching a
+// family of codes. retryAfter is the minimum delay before retrying, if any.
+//
+// Exact codes take precedence over other keys, which are tried in the order
+// they were registered. RegisterClass panics if key is a PositionKey.
+func RegisterClass(key interface{}, class Class, retryAfter time.Duration) {
+	mustMatchCodes(key)
+	classRegistry.Lock()
+	defer classRegistry.Unlock()
+	rule := classRule{key, class, retryAfter}
+	if code, ok := key.(string); ok && !isGlob(code) {
+		if classRegistry.codes == nil {
+			classRegistry.codes = make(map[string]classRule)
+		}
+		classRegistry.codes[code] = rule
+		return
+	}
+	classRegistry.rules = append(classRegistry.rules, rule)
+}
+
+// registeredClass returns the class registered for code.
+func registeredClass(code string) (Class, time.Duration, bool) {
+	classRegistry.RLock()
+	defer classRegistry.RUnlock()
+	if rule, ok := classRegistry.codes[code]; ok {
+		return rule.class, rule.retryAfter, true
+	}
+	coded := &withErrCode{code: code}
+	for _, rule := range classRegistry.rules {
+		if coded.Match(rule.key) {
+			return rule.class, rule.retryAfter, true
+		}
+	}
+	return Unclassified, 0, false
+}
+
+type withClass struct {
+	class      Class
+	retryAfter time.Duration
+	cause      error
+}
+
+func (c *withClass) Error() string {
+	return c.cause.Error()
+}
+
+// Class returns the class of the error and the retry-after duration.
+func (c *withClass) Class() (Class, time.Duration) {
+	return c.class, c.retryAfter
+}
+
+// Unwrap provides compatibility for Go 1.13 error chains.
+func (c *withClass) Unwrap() error {
+	return c.cause
+}
+
+func (c *withClass) Format(s fmt.State, verb rune) {
+	format(s, verb, c, c)
+}
+
+func (c *withClass) formatChain(s fmt.State, verb rune, ch chain) {
+	fmt.Fprintf(s, causeFormat(s, verb), ch.link(c.cause))
+}
+
+// WithClass annotates err with a retry class, overriding the class
+// registered for its codes.
+// If err is nil, WithClass returns nil.
+func WithClass(err error, class Class) error {
+	if err == nil {
+		return nil
+	}
+	return &withClass{class, 0, err}
+}
+
+// WithRetryAfter annotates err as Throttled, retryable after d.
+// If err is nil, WithRetryAfter returns nil.
+func WithRetryAfter(err error, d time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &withClass{Throttled, d, err}
+}
+
+// ClassOf returns the class of the first error in err's chain that is
+// classified, either by a method Class() (Class, time.Duration) or by the
+// class registered for its code, and the retry-after duration.
+func ClassOf(err error) (Class, time.Duration) {
+	var class Class
+	var retryAfter time.Duration
+	found := Find(err, func(link error) bool {
+		if x, ok := link.(interface{ Class() (Class, time.Duration) }); ok {
+			if class, retryAfter = x.Class(); class != Unclassified {
+				return true
+			}
+		}
+		if x, ok := link.(interface{ Code() string }); ok {
+			var found bool
+			class, retryAfter, found = registeredClass(x.Code())
+			return found
+		}
+		return false
+	})
+	if found == nil {
+		return Unclassified, 0
+	}
+	return class, retryAfter
+}
+
+// IsRetryable reports whether err is classified as Retryable or Throttled.
+func IsRetryable(err error) bool {
+	class, _ := ClassOf(err)
+	return class == Retryable || class == Throttled
+}
+
+// IsPermanent reports whether err is classified as Permanent.
+func IsPermanent(err error) bool {
+	class, _ := ClassOf(err)
+	return class == Permanent
+}
+
+// RetryAfter returns the retry-after duration of err's class, if any.
+func RetryAfter(err error) (time.Duration, bool) {
+	_, retryAfter := ClassOf(err)
+	return retryAfter, retryAfter > 0
+}
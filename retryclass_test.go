@@ -0,0 +1,87 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors_test
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/nextf/errors"
+)
+
+func init() {
+	errors.RegisterClass(regexp.MustCompile("^RC_TEC_"), errors.Retryable, 0)
+	errors.RegisterClass("RC_TEC_Auth", errors.Permanent, 0)
+	errors.RegisterClass("RC_TEC_Quota", errors.Throttled, time.Second)
+	errors.RegisterClass("RC_BIS_*Conflict", errors.Retryable, 0)
+}
+
+func TestClassOf(t *testing.T) {
+	tests := []struct {
+		err        error
+		class      errors.Class
+		retryAfter time.Duration
+	}{
+		{errors.ErrCode("RC_TEC_Timeout", "Timeout"), errors.Retryable, 0},
+		{errors.ErrCode("RC_TEC_Auth", "Access denied"), errors.Permanent, 0},
+		{errors.ErrCode("RC_TEC_Quota", "Too many requests"), errors.Throttled, time.Second},
+		{errors.ErrCode("RC_BIS_Order", "Not found"), errors.Unclassified, 0},
+		{errors.ErrCode("RC_BIS_OrderConflict", "Conflict"), errors.Retryable, 0},
+		{errors.Wrap(errors.ErrCode("RC_TEC_Auth", "Access denied"), "RC_BIS_Order", "Query failed"), errors.Permanent, 0},
+		{errors.WithClass(errors.ErrCode("RC_TEC_Auth", "Access denied"), errors.Retryable), errors.Retryable, 0},
+		{errors.WithRetryAfter(errors.New("Slow down"), time.Minute), errors.Throttled, time.Minute},
+		{errors.WithClass(errors.WithRetryAfter(errors.New("Slow down"), time.Minute), errors.Unclassified), errors.Throttled, time.Minute},
+		{nil, errors.Unclassified, 0},
+	}
+	for _, tt := range tests {
+		class, retryAfter := errors.ClassOf(tt.err)
+		if class != tt.class || retryAfter != tt.retryAfter {
+			t.Errorf("%v: Expect %v %v, got %v %v", tt.err, tt.class, tt.retryAfter, class, retryAfter)
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	if !errors.IsRetryable(errors.ErrCode("RC_TEC_Timeout", "Timeout")) {
+		t.Errorf("Expect %v, got %v", true, false)
+	}
+	if !errors.IsRetryable(errors.ErrCode("RC_TEC_Quota", "Too many requests")) {
+		t.Errorf("Expect %v, got %v", true, false)
+	}
+	if errors.IsRetryable(errors.ErrCode("RC_TEC_Auth", "Access denied")) {
+		t.Errorf("Expect %v, got %v", false, true)
+	}
+	if !errors.IsPermanent(errors.ErrCode("RC_TEC_Auth", "Access denied")) {
+		t.Errorf("Expect %v, got %v", true, false)
+	}
+	if d, ok := errors.RetryAfter(errors.ErrCode("RC_TEC_Quota", "Too many requests")); !ok || d != time.Second {
+		t.Errorf("Expect %v, got %v", time.Second, d)
+	}
+	if _, ok := errors.RetryAfter(errors.ErrCode("RC_TEC_Timeout", "Timeout")); ok {
+		t.Errorf("Expect %v, got %v", false, true)
+	}
+}
+
+func TestWithClassFormat(t *testing.T) {
+	err := errors.WithClass(errors.WithErrCode(errors.New("Not found file"), "NOT_FOUND", "Not found index.html"), errors.Permanent)
+	if text := fmt.Sprintf("%+v", err); text != "[NOT_FOUND] Not found index.html\nCaused by: Not found file" {
+		t.Errorf("Expected message was not obtained\n%s", text)
+	}
+	if text := fmt.Sprintf("%s", err); text != "Not found index.html" {
+		t.Errorf("Expect %s, got %s", "Not found index.html", text)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Sampler decides whether an error records its full call stack.
+// The errors that are not sampled still record the frame of their caller.
+// A Sampler must be safe for concurrent use.
+type Sampler interface {
+	Sample() bool
+}
+
+type everyN struct {
+	n     uint64
+	count atomic.Uint64
+}
+
+// EveryN returns a sampler sampling the first of every n errors.
+func EveryN(n int) Sampler {
+	if n < 1 {
+		n = 1
+	}
+	return &everyN{n: uint64(n)}
+}
+
+func (s *everyN) Sample() bool {
+	return (s.count.Add(1)-1)%s.n == 0
+}
+
+type atMost struct {
+	k        int
+	interval time.Duration
+	now      func() time.Time
+
+	mu    sync.Mutex
+	start time.Time
+	count int
+}
+
+// AtMost returns a sampler sampling at most k errors per interval.
+func AtMost(k int, interval time.Duration) Sampler {
+	return &atMost{k: k, interval: interval, now: time.Now}
+}
+
+func (s *atMost) Sample() bool {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.start.IsZero() || !now.Before(s.start.Add(s.interval)) {
+		s.start = now
+		s.count = 0
+	}
+	if s.count >= s.k {
+		return false
+	}
+	s.count++
+	return true
+}
+
+type samplingRule struct {
+	key     interface{}
+	sampler Sampler
+}
+
+type samplingRules struct {
+	codes map[string]*samplingRule
+	rules []*samplingRule
+}
+
+var (
+	samplingMu sync.Mutex
+	sampling   atomic.Pointer[samplingRules]
+)
+
+// RegisterSampling samples the call stacks recorded by Wrap, Wrapf, WrapNodup,
+// WrapNodupf, TraceableErrCode and TraceableErrCodef for the codes matching
+// key, and by Trace and TraceNodup for the errors whose code matches key.
+// key is a code or any other key accepted by Match, such as a regexp matching
+// a family of codes. A family shares its sampler among its codes.
+//
+// Exact codes take precedence over other keys, which are tried in the order
+// they were registered. If key is a code and s is nil, the sampling of the
+// code is removed. RegisterSampling returns a function that removes the
+// sampling it registered, and panics if key is a PositionKey.
+//
+// Sampling is meant for hot codes whose call stacks are always the same.
+func RegisterSampling(key interface{}, s Sampler) (remove func()) {
+	mustMatchCodes(key)
+	rule := &samplingRule{key, s}
+	updateSampling(func(next *samplingRules) {
+		if code, ok := key.(string); ok && !isGlob(code) {
+			delete(next.codes, code)
+			if s != nil {
+				next.codes[code] = rule
+			}
+		} else if s != nil {
+			next.rules = append(next.rules, rule)
+		}
+	})
+	var once sync.Once
+	return func() {
+		once.Do(func() { updateSampling(func(next *samplingRules) { removeSampling(next, rule) }) })
+	}
+}
+
+func removeSampling(next *samplingRules, rule *samplingRule) {
+	for code, r := range next.codes {
+		if r == rule {
+			delete(next.codes, code)
+		}
+	}
+	rules := next.rules[:0]
+	for _, r := range next.rules {
+		if r != rule {
+			rules = append(rules, r)
+		}
+	}
+	next.rules = rules
+}
+
+// updateSampling replaces the registered sampling by a copy changed by update.
+func updateSampling(update func(next *samplingRules)) {
+	samplingMu.Lock()
+	defer samplingMu.Unlock()
+	next := &samplingRules{codes: make(map[string]*samplingRule)}
+	if prev := sampling.Load(); prev != nil {
+		for code, rule := range prev.codes {
+			next.codes[code] = rule
+		}
+		next.rules = append(next.rules, prev.rules...)
+	}
+	update(next)
+	if len(next.codes) == 0 && len(next.rules) == 0 {
+		next = nil
+	}
+	sampling.Store(next)
+}
+
+// samplingCode returns the code deciding the sampling of err's call stack,
+// or "" if no sampling is registered.
+func samplingCode(err error) string {
+	if sampling.Load() == nil {
+		return ""
+	}
+	code, _ := GetCode(err)
+	return code
+}
+
+// stackDepth returns the maximum depth of the call stack recorded for code,
+// and whether a sampling is registered for code.
+func stackDepth(code string) (int, bool) {
+	rules := sampling.Load()
+	if rules == nil || code == "" {
+		return maxDepth, false
+	}
+	rule, ok := rules.codes[code]
+	if !ok {
+		coded := &withErrCode{code: code}
+		for _, r := range rules.rules {
+			if coded.Match(r.key) {
+				rule, ok = r, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return maxDepth, false
+	}
+	if rule.sampler.Sample() {
+		return maxDepth, true
+	}
+	return 1, true
+}
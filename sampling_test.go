@@ -0,0 +1,98 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/nextf/errors"
+	"github.com/nextf/errors/stack"
+)
+
+func stackDepth(err error) int {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if x, ok := err.(interface{ StackTrace() []stack.Frame }); ok {
+			return len(x.StackTrace())
+		}
+	}
+	return 0
+}
+
+func TestEveryN(t *testing.T) {
+	errors.RegisterSampling("SMP_Hot", errors.EveryN(3))
+	defer errors.RegisterSampling("SMP_Hot", nil)
+	root := errors.New("[SMP_Root] Root level")
+	var depths []int
+	for i := 0; i < 5; i++ {
+		depths = append(depths, stackDepth(errors.Wrap(root, "SMP_Hot", "Level 1")))
+	}
+	for i, depth := range depths {
+		if sampled := i%3 == 0; sampled != (depth > 1) {
+			t.Errorf("%d: Expect sampled=%v, got depth %d", i, sampled, depth)
+		}
+	}
+	if err := errors.Wrap(root, "SMP_Hot", "Level 1"); stackDepth(err) != 1 || !errors.Match(err, "SMP_Hot") {
+		t.Errorf("Expect the code and the caller frame, got %+v", err)
+	}
+	err := errors.Wrap(root, "SMP_Hot", "Level 1")
+	if frames := err.(interface{ Unwrap() error }).Unwrap().(interface{ StackTrace() []stack.Frame }).StackTrace(); frames[0].Function != "github.com/nextf/errors_test.TestEveryN" {
+		t.Errorf("Expect the caller frame, got %s", frames[0].Describe())
+	}
+	if depth := stackDepth(errors.Wrap(root, "SMP_Cold", "Level 1")); depth <= 1 {
+		t.Errorf("Expect a full call stack, got depth %d", depth)
+	}
+}
+
+func TestAtMost(t *testing.T) {
+	remove := errors.RegisterSampling(regexp.MustCompile("^SMPF_"), errors.AtMost(2, time.Hour))
+	sampled := 0
+	for i := 0; i < 5; i++ {
+		if stackDepth(errors.TraceableErrCode("SMPF_Hot", "Hot")) > 1 {
+			sampled++
+		}
+		if stackDepth(errors.Trace(errors.ErrCode("SMPF_Other", "Other"))) > 1 {
+			sampled++
+		}
+	}
+	if sampled != 2 {
+		t.Errorf("Expect %d sampled, got %d", 2, sampled)
+	}
+	remove()
+	remove()
+	if depth := stackDepth(errors.TraceableErrCode("SMPF_Hot", "Hot")); depth <= 1 {
+		t.Errorf("Expect a full call stack once removed, got depth %d", depth)
+	}
+}
+
+func findSampledOrder(id int) error {
+	return errors.TraceableErrCodef("SMP_Order", "Not found order %d", id)
+}
+
+func TestSamplingFingerprint(t *testing.T) {
+	remove := errors.RegisterSampling("SMP_Order", errors.EveryN(2))
+	defer remove()
+	sampled, unsampled := findSampledOrder(1), findSampledOrder(2)
+	if stackDepth(sampled) <= 1 || stackDepth(unsampled) != 1 {
+		t.Fatalf("Expect a sampled and an unsampled error, got depths %d and %d", stackDepth(sampled), stackDepth(unsampled))
+	}
+	if errors.Fingerprint(sampled) != errors.Fingerprint(unsampled) {
+		t.Errorf("Expect the same fingerprint for sampled and unsampled errors")
+	}
+	if errors.Fingerprint(sampled) == errors.Fingerprint(errors.TraceableErrCode("SMP_Order", "Not found")) {
+		t.Errorf("Expect a different fingerprint for a different caller")
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+type withSuppressed struct {
+	cause      error
+	suppressed []error
+}
+
+func (c *withSuppressed) Error() string {
+	return c.cause.Error()
+}
+
+// Suppressed returns the errors suppressed in favor of the cause.
+func (c *withSuppressed) Suppressed() []error {
+	return c.suppressed
+}
+
+// Unwrap provides compatibility for Go 1.13 error chains.
+// The suppressed errors are not part of the chain.
+func (c *withSuppressed) Unwrap() error {
+	return c.cause
+}
+
+func (c *withSuppressed) Format(s fmt.State, verb rune) {
+	format(s, verb, c, c)
+}
+
+func (c *withSuppressed) formatChain(s fmt.State, verb rune, ch chain) {
+	format := causeFormat(s, verb)
+	fmt.Fprintf(s, format, ch.link(c.cause))
+	if verb != 'v' || !s.Flag('+') {
+		return
+	}
+	for _, err := range c.suppressed {
+		text := fmt.Sprintf(format, ch.link(err))
+		io.WriteString(s, "\nSuppressed: ")
+		io.WriteString(s, strings.ReplaceAll(text, "\n", "\n"+tab))
+	}
+}
+
+const tab string = "\x20\x20\x20\x20"
+
+// AddSuppressed returns an error annotating primary with secondary, an error
+// that occurred while handling primary, such as the failure to close a file
+// after a failed write, so that secondary is not lost.
+//
+// The suppressed errors are printed by %+v after the chain of primary, but
+// are not part of the chain: Is, As, Match and GetCode ignore them.
+// If primary is nil, AddSuppressed returns secondary.
+// If secondary is nil, AddSuppressed returns primary.
+func AddSuppressed(primary, secondary error) error {
+	if primary == nil {
+		return secondary
+	}
+	if secondary == nil {
+		return primary
+	}
+	if x, ok := primary.(*withSuppressed); ok {
+		suppressed := append(x.suppressed[:len(x.suppressed):len(x.suppressed)], secondary)
+		return built("AddSuppressed", "", primary, &withSuppressed{x.cause, suppressed})
+	}
+	return built("AddSuppressed", "", primary, &withSuppressed{primary, []error{secondary}})
+}
+
+// Suppressed returns the errors suppressed in err's chain, outermost first.
+func Suppressed(err error) []error {
+	var suppressed []error
+	walk(err, func(link error, _ int) bool {
+		if x, ok := link.(interface{ Suppressed() []error }); ok {
+			suppressed = append(suppressed, x.Suppressed()...)
+		}
+		return true
+	})
+	return suppressed
+}
+
+// Close closes c and records its failure in *err, annotated with a call
+// stack, an error code and a message that is formatted according to the
+// format specifier. It is meant to be deferred by functions with a named
+// error result:
+//
+//	func writeFile(name string, data []byte) (err error) {
+//		f, err := os.Create(name)
+//		if err != nil {
+//			return errors.Wrap(err, "IO_TEC_Create", "create failed")
+//		}
+//		defer errors.Close(&err, f, "IO_TEC_Close", "close %s", name)
+//		...
+//	}
+//
+// If *err is nil, the failure becomes the error, otherwise it is added to
+// *err as suppressed. If err is nil, c is closed and its failure ignored.
+func Close(err *error, c io.Closer, code, format string, args ...interface{}) {
+	cerr := c.Close()
+	if err == nil || cerr == nil {
+		return
+	}
+	closeErr := built("Close", code, cerr, &withErrCode{code, fmt.Sprintf(format, args...), withErrorStack(cerr, 1, code)})
+	if *err == nil {
+		*err = closeErr
+	} else {
+		*err = AddSuppressed(*err, closeErr)
+	}
+}
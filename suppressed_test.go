@@ -0,0 +1,90 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/nextf/errors"
+)
+
+type closer struct{ err error }
+
+func (c *closer) Close() error { return c.err }
+
+func TestAddSuppressed(t *testing.T) {
+	primary := errors.ErrCode("IO_Write", "Write failed")
+	if err := errors.AddSuppressed(primary, nil); err != primary {
+		t.Errorf("Expect %v, got %v", primary, err)
+	}
+	if err := errors.AddSuppressed(nil, primary); err != primary {
+		t.Errorf("Expect %v, got %v", primary, err)
+	}
+	s1 := errors.ErrCode("IO_Close", "Close failed")
+	s2 := errors.WithErrCode(errors.New("Disk full"), "IO_Flush", "Flush failed")
+	e1 := errors.AddSuppressed(primary, s1)
+	e2 := errors.AddSuppressed(e1, s2)
+	if suppressed := errors.Suppressed(e1); len(suppressed) != 1 || suppressed[0] != s1 {
+		t.Errorf("Expect %v, got %v", []error{s1}, suppressed)
+	}
+	if suppressed := errors.Suppressed(errors.WithErrCode(e2, "SVC", "Save failed")); len(suppressed) != 2 || suppressed[1] != s2 {
+		t.Errorf("Expect %v, got %v", []error{s1, s2}, suppressed)
+	}
+	if !errors.Is(e2, primary) || errors.Is(e2, s1) || errors.Match(e2, "IO_Flush") {
+		t.Errorf("Expect the suppressed errors out of the chain")
+	}
+	if e2.Error() != "Write failed" || fmt.Sprintf("%v", e2) != "[IO_Write] Write failed" {
+		t.Errorf("Unexpected message %v", e2)
+	}
+	text := fmt.Sprintf("%+v", e2)
+	want := "[IO_Write] Write failed\nSuppressed: [IO_Close] Close failed\nSuppressed: [IO_Flush] Flush failed\n    Caused by: Disk full"
+	if text != want {
+		t.Errorf("Expect\n%s\ngot\n%s", want, text)
+	}
+}
+
+func writeAndClose(c *closer, writeErr error) (err error) {
+	defer errors.Close(&err, c, "IO_TEC_Close", "close %s", "data.txt")
+	return writeErr
+}
+
+func TestClose(t *testing.T) {
+	if err := writeAndClose(&closer{}, nil); err != nil {
+		t.Errorf("Expect %v, got %v", nil, err)
+	}
+	closeErr := errors.New("Bad file descriptor")
+	err := writeAndClose(&closer{closeErr}, nil)
+	if !errors.Match(err, "IO_TEC_Close") || !errors.Is(err, closeErr) {
+		t.Errorf("Expect code=%s, got %v", "IO_TEC_Close", err)
+	}
+	if err.Error() != "close data.txt" {
+		t.Errorf("Expect %q, got %q", "close data.txt", err.Error())
+	}
+	if text := fmt.Sprintf("%+v", err); !strings.Contains(text, "errors_test.writeAndClose(suppressed_test.go:") {
+		t.Errorf("Expect the call stack of writeAndClose, got\n%s", text)
+	}
+	writeErr := errors.ErrCode("IO_Write", "Write failed")
+	err = writeAndClose(&closer{closeErr}, writeErr)
+	if !errors.Is(err, writeErr) || errors.Match(err, "IO_TEC_Close") {
+		t.Errorf("Expect %v, got %v", writeErr, err)
+	}
+	if suppressed := errors.Suppressed(err); len(suppressed) != 1 || !errors.Is(suppressed[0], closeErr) {
+		t.Errorf("Expect %v suppressed, got %v", closeErr, suppressed)
+	}
+	c := &closer{closeErr}
+	errors.Close(nil, c, "IO_TEC_Close", "close")
+}
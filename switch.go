@@ -0,0 +1,114 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import "reflect"
+
+// Switcher dispatches an error to the handler of the first case whose key
+// matches it, like a switch statement over Match. Create it with Switch:
+//
+//	return errors.Switch(err).
+//		Case("NF_*", func(err error) error { return nil }).
+//		Case(regexp.MustCompile("^AD_"), denied).
+//		Default(func(err error) error { return errors.Wrap(err, "API_Order", "Order failed") })
+type Switcher struct {
+	err     error
+	matched bool
+	result  error
+}
+
+// Switch returns a Switcher for err. If err is nil, no case matches it and
+// Default returns nil without calling its handler.
+func Switch(err error) *Switcher {
+	return &Switcher{err: err}
+}
+
+// Case calls fn with the error and keeps its result, if no previous case
+// matched and Match(err, key) is true.
+func (s *Switcher) Case(key interface{}, fn func(err error) error) *Switcher {
+	if !s.matched && s.err != nil && matchLink(s.err, key, nil) != nil {
+		s.matched, s.result = true, fn(s.err)
+	}
+	return s
+}
+
+// CaseAs is like Case, but only matches an error in the chain that both
+// matches key and is assignable to the value pointed to by target, like As.
+// target is set to that error before fn is called.
+//
+// CaseAs panics if target is not a non-nil pointer to either a type that
+// implements error, or to any interface type.
+func (s *Switcher) CaseAs(key interface{}, target interface{}, fn func(err error) error) *Switcher {
+	val := asTarget(target)
+	if s.matched || s.err == nil {
+		return s
+	}
+	typ := val.Type().Elem()
+	link := matchLink(s.err, key, func(link error) bool {
+		return reflect.TypeOf(link).AssignableTo(typ)
+	})
+	if link != nil {
+		val.Elem().Set(reflect.ValueOf(link))
+		s.matched, s.result = true, fn(s.err)
+	}
+	return s
+}
+
+// Default returns the result of the handler of the matching case. If no case
+// matched, Default returns fn(err), or err itself if fn is nil.
+func (s *Switcher) Default(fn func(err error) error) error {
+	switch {
+	case s.matched:
+		return s.result
+	case s.err == nil || fn == nil:
+		return s.err
+	}
+	return fn(s.err)
+}
+
+// Handler is a case of Handle.
+type Handler struct {
+	Key interface{}
+	Fn  func(err error) error
+}
+
+// Handle calls the Fn of the first handler whose Key matches err and returns
+// its result. If no handler matches, Handle returns err. Handlers are tried
+// in order, so a table of handlers behaves like a Switcher.
+func Handle(err error, handlers []Handler) error {
+	s := Switch(err)
+	for _, h := range handlers {
+		s.Case(h.Key, h.Fn)
+	}
+	return s.Default(nil)
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// asTarget validates target like As.
+func asTarget(target interface{}) reflect.Value {
+	if target == nil {
+		panic("errors: target cannot be nil")
+	}
+	val := reflect.ValueOf(target)
+	typ := val.Type()
+	if typ.Kind() != reflect.Ptr || val.IsNil() {
+		panic("errors: target must be a non-nil pointer")
+	}
+	if e := typ.Elem(); e.Kind() != reflect.Interface && !e.Implements(errorType) {
+		panic("errors: *target must be interface or implement error")
+	}
+	return val
+}
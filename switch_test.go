@@ -0,0 +1,124 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/nextf/errors"
+)
+
+func TestSwitch(t *testing.T) {
+	handled := errors.New("handled")
+	var called []string
+	handler := func(name string) func(error) error {
+		return func(error) error {
+			called = append(called, name)
+			return handled
+		}
+	}
+	err := errors.Wrap(errors.ErrCode("NF_BIS_Order", "Not found"), "API_Order", "Order failed")
+
+	called = nil
+	result := errors.Switch(err).
+		Case("AD_*", handler("AD")).
+		Case(regexp.MustCompile("^NF_"), handler("NF")).
+		Case("API_Order", handler("API")).
+		Default(handler("default"))
+	if result != handled || len(called) != 1 || called[0] != "NF" {
+		t.Errorf("Expect [NF], got %v", called)
+	}
+
+	called = nil
+	result = errors.Switch(err).Case(errors.Innermost("API_*"), handler("API")).Default(handler("default"))
+	if result != handled || len(called) != 1 || called[0] != "default" {
+		t.Errorf("Expect [default], got %v", called)
+	}
+
+	if result = errors.Switch(err).Case("AD_*", handler("AD")).Default(nil); result != err {
+		t.Errorf("Expect %v, got %v", err, result)
+	}
+
+	called = nil
+	if result = errors.Switch(nil).Case("*", handler("any")).Default(handler("default")); result != nil || called != nil {
+		t.Errorf("Expect no handler to be called, got %v", called)
+	}
+}
+
+type validationError struct {
+	code  string
+	field string
+}
+
+func (e *validationError) Error() string { return "invalid " + e.field }
+func (e *validationError) Code() string  { return e.code }
+func (e *validationError) Match(key interface{}) bool {
+	return errors.Compile(key).MatchString(e.code)
+}
+
+func TestSwitchCaseAs(t *testing.T) {
+	err := errors.Wrap(&validationError{"BIS_Invalid", "name"}, "API_Order", "Order failed")
+	var coded *errors.ConstError
+	var invalid *validationError
+	var field string
+	result := errors.Switch(err).
+		CaseAs("*", &coded, func(error) error { return errors.New("const") }).
+		CaseAs("BIS_*", &invalid, func(error) error {
+			field = invalid.field
+			return nil
+		}).
+		Default(func(err error) error { return err })
+	if result != nil || field != "name" {
+		t.Errorf("Expect the field name, got %q, %v", field, result)
+	}
+
+	var link interface{ Code() string }
+	errors.Switch(err).CaseAs("*", &link, func(error) error { return nil })
+	if link == nil || link.Code() != "API_Order" {
+		t.Errorf("Expect the outermost link, got %v", link)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expect CaseAs to panic on an invalid target")
+		}
+	}()
+	errors.Switch(err).CaseAs("*", invalid, func(error) error { return nil })
+}
+
+func TestHandle(t *testing.T) {
+	retried := errors.New("retried")
+	handlers := []errors.Handler{
+		{errors.MustParseMatcher("IO_TEC_* & !IO_TEC_Auth"), func(error) error { return retried }},
+		{"NF_*", func(error) error { return nil }},
+	}
+	tests := []struct {
+		err, want error
+	}{
+		{errors.ErrCode("IO_TEC_Timeout", "Timeout"), retried},
+		{errors.Wrap(errors.ErrCode("NF_BIS_Order", "Not found"), "API_Order", "Order failed"), nil},
+		{nil, nil},
+	}
+	for _, tt := range tests {
+		if got := errors.Handle(tt.err, handlers); got != tt.want {
+			t.Errorf("Handle(%v): expect %v, got %v", tt.err, tt.want, got)
+		}
+	}
+	auth := errors.ErrCode("IO_TEC_Auth", "Access denied")
+	if got := errors.Handle(auth, handlers); got != auth {
+		t.Errorf("Handle(%v): expect %v, got %v", auth, auth, got)
+	}
+}
@@ -0,0 +1,446 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package codeswitch defines an Analyzer that reports switches and Match
+// cascades that do not handle every code of an errors.CodeGroup.
+//
+// A group is a package-level variable initialized with constant codes:
+//
+//	var OrderCodes = errors.NewCodeGroup("NF_BIS_Order", "AD_TEC_DbConnect")
+//
+// A switch is checked against a group when its tag was obtained from the
+// group's Of method, or from errors.GetCode and every case names a code of
+// exactly one group:
+//
+//	code, _ := errors.GetCode(err)
+//	switch code {
+//	case "NF_BIS_Order":
+//	}
+//
+// A chain of if-else statements whose conditions call errors.Match on the
+// same error with constant codes is checked in the same way.
+//
+// A default clause or a final else does not make a switch exhaustive,
+// unless the -default-signifies-exhaustive flag is set.
+package codeswitch
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const errorsPath = "github.com/nextf/errors"
+
+var Analyzer = &analysis.Analyzer{
+	Name:      "codeswitch",
+	Doc:       "check that switches on error codes handle every code of a group",
+	URL:       "https://pkg.go.dev/github.com/nextf/errors/tools/analysis/codeswitch",
+	Requires:  []*analysis.Analyzer{inspect.Analyzer},
+	Run:       run,
+	FactTypes: []analysis.Fact{new(groupFact)},
+}
+
+var defaultSignifiesExhaustive bool
+
+func init() {
+	Analyzer.Flags.BoolVar(&defaultSignifiesExhaustive, "default-signifies-exhaustive", false,
+		"treat a default clause or final else as handling the remaining codes")
+}
+
+// groupFact records the codes of a CodeGroup variable.
+type groupFact struct {
+	Codes []string
+}
+
+func (*groupFact) AFact() {}
+
+func (f *groupFact) String() string {
+	return "codes(" + strings.Join(f.Codes, ", ") + ")"
+}
+
+// group is a CodeGroup variable visible to the package being analyzed.
+type group struct {
+	obj   types.Object
+	codes []string
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	exportGroups(pass)
+	var groups []group
+	for _, f := range pass.AllObjectFacts() {
+		if fact, ok := f.Fact.(*groupFact); ok {
+			groups = append(groups, group{f.Object, fact.Codes})
+		}
+	}
+	if len(groups) == 0 {
+		return nil, nil
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groupName(pass, groups[i].obj) < groupName(pass, groups[j].obj)
+	})
+	groupOf := func(obj types.Object) *group {
+		for i := range groups {
+			if groups[i].obj == obj {
+				return &groups[i]
+			}
+		}
+		return nil
+	}
+
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	nodeFilter := []ast.Node{
+		(*ast.FuncDecl)(nil),
+		(*ast.FuncLit)(nil),
+	}
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		var body *ast.BlockStmt
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			body = n.Body
+		case *ast.FuncLit:
+			body = n.Body
+		}
+		if body == nil {
+			return
+		}
+		sources := codeVars(pass, body)
+		elses := make(map[*ast.IfStmt]bool)
+		ast.Inspect(body, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncLit:
+				// Checked on its own.
+				return false
+			case *ast.SwitchStmt:
+				checkSwitch(pass, n, sources, groups, groupOf)
+			case *ast.IfStmt:
+				if next, ok := n.Else.(*ast.IfStmt); ok {
+					elses[next] = true
+				}
+				if !elses[n] {
+					checkCascade(pass, n, groups)
+				}
+			}
+			return true
+		})
+	})
+	return nil, nil
+}
+
+// exportGroups exports a fact for each package-level variable initialized
+// by NewCodeGroup with constant codes.
+func exportGroups(pass *analysis.Pass) {
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				vspec, ok := spec.(*ast.ValueSpec)
+				if !ok || len(vspec.Names) != len(vspec.Values) {
+					continue
+				}
+				for i, name := range vspec.Names {
+					codes, ok := groupCodes(pass, vspec.Values[i])
+					if obj := pass.TypesInfo.Defs[name]; ok && obj != nil {
+						pass.ExportObjectFact(obj, &groupFact{codes})
+					}
+				}
+			}
+		}
+	}
+}
+
+func groupCodes(pass *analysis.Pass, expr ast.Expr) ([]string, bool) {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok || !isErrorsFunc(pass, call, "NewCodeGroup") || call.Ellipsis.IsValid() {
+		return nil, false
+	}
+	var codes []string
+	seen := make(map[string]bool)
+	for _, arg := range call.Args {
+		code, ok := stringConst(pass, arg)
+		if !ok {
+			return nil, false
+		}
+		if !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+	return codes, true
+}
+
+// codeSource describes where a variable holding an error code comes from:
+// the group whose Of method returned it, or nil for errors.GetCode.
+type codeSource struct {
+	group types.Object
+}
+
+// codeVars finds the variables of body that are only assigned an error code
+// by a call to errors.GetCode or to the Of method of a CodeGroup.
+func codeVars(pass *analysis.Pass, body *ast.BlockStmt) map[types.Object]*codeSource {
+	sources := make(map[types.Object]*codeSource)
+	invalid := make(map[types.Object]bool)
+	assign := func(lhs ast.Expr, source *codeSource) {
+		id, ok := lhs.(*ast.Ident)
+		if !ok {
+			return
+		}
+		obj := pass.TypesInfo.ObjectOf(id)
+		if obj == nil || invalid[obj] {
+			return
+		}
+		if prev, ok := sources[obj]; source == nil || (ok && *prev != *source) {
+			invalid[obj] = true
+			delete(sources, obj)
+			return
+		}
+		sources[obj] = source
+	}
+	assignStmt := func(lhs []ast.Expr, rhs []ast.Expr) {
+		var source *codeSource
+		if len(rhs) == 1 {
+			source = codeCall(pass, rhs[0])
+		}
+		for i, x := range lhs {
+			if i == 0 {
+				assign(x, source)
+			} else {
+				assign(x, nil)
+			}
+		}
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			assignStmt(n.Lhs, n.Rhs)
+		case *ast.ValueSpec:
+			lhs := make([]ast.Expr, len(n.Names))
+			for i, name := range n.Names {
+				lhs[i] = name
+			}
+			assignStmt(lhs, n.Values)
+		case *ast.UnaryExpr:
+			if n.Op == token.AND {
+				// The variable may be modified through its address.
+				assign(n.X, nil)
+			}
+		}
+		return true
+	})
+	return sources
+}
+
+func codeCall(pass *analysis.Pass, expr ast.Expr) *codeSource {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+	if isErrorsFunc(pass, call, "GetCode") {
+		return &codeSource{}
+	}
+	if recv := groupMethod(pass, call, "Of"); recv != nil {
+		return &codeSource{recv}
+	}
+	return nil
+}
+
+func checkSwitch(pass *analysis.Pass, stmt *ast.SwitchStmt, sources map[types.Object]*codeSource, groups []group, groupOf func(types.Object) *group) {
+	id, ok := ast.Unparen(stmt.Tag).(*ast.Ident)
+	if !ok {
+		return
+	}
+	source, ok := sources[pass.TypesInfo.ObjectOf(id)]
+	if !ok {
+		return
+	}
+	handled := make(map[string]bool)
+	hasDefault := false
+	for _, clause := range stmt.Body.List {
+		clause := clause.(*ast.CaseClause)
+		if clause.List == nil {
+			hasDefault = true
+		}
+		for _, expr := range clause.List {
+			code, ok := stringConst(pass, expr)
+			if !ok {
+				return
+			}
+			handled[code] = true
+		}
+	}
+	if hasDefault && defaultSignifiesExhaustive {
+		return
+	}
+	var g *group
+	if source.group != nil {
+		g = groupOf(source.group)
+	} else {
+		g = inferGroup(groups, handled)
+	}
+	if g != nil {
+		report(pass, stmt, "switch", g, handled)
+	}
+}
+
+func checkCascade(pass *analysis.Pass, stmt *ast.IfStmt, groups []group) {
+	handled := make(map[string]bool)
+	var target string
+	for s := stmt; ; {
+		for _, cond := range disjuncts(s.Cond) {
+			call, ok := cond.(*ast.CallExpr)
+			if !ok || !isErrorsFunc(pass, call, "Match") || len(call.Args) != 2 {
+				return
+			}
+			if x := types.ExprString(call.Args[0]); target == "" {
+				target = x
+			} else if x != target {
+				return
+			}
+			code, ok := stringConst(pass, call.Args[1])
+			if !ok {
+				return
+			}
+			handled[code] = true
+		}
+		next, ok := s.Else.(*ast.IfStmt)
+		if !ok {
+			if s.Else != nil && defaultSignifiesExhaustive {
+				return
+			}
+			break
+		}
+		s = next
+	}
+	if len(handled) < 2 {
+		return
+	}
+	if g := inferGroup(groups, handled); g != nil {
+		report(pass, stmt, "Match cascade", g, handled)
+	}
+}
+
+// disjuncts splits x || y || ... into its operands.
+func disjuncts(expr ast.Expr) []ast.Expr {
+	expr = ast.Unparen(expr)
+	if bin, ok := expr.(*ast.BinaryExpr); ok && bin.Op == token.LOR {
+		return append(disjuncts(bin.X), disjuncts(bin.Y)...)
+	}
+	return []ast.Expr{expr}
+}
+
+// inferGroup returns the only group containing all handled codes.
+func inferGroup(groups []group, handled map[string]bool) *group {
+	if len(handled) == 0 {
+		return nil
+	}
+	var found *group
+	for i := range groups {
+		g := &groups[i]
+		contains := make(map[string]bool, len(g.codes))
+		for _, code := range g.codes {
+			contains[code] = true
+		}
+		all := true
+		for code := range handled {
+			if !contains[code] {
+				all = false
+				break
+			}
+		}
+		if !all {
+			continue
+		}
+		if found != nil {
+			return nil
+		}
+		found = g
+	}
+	return found
+}
+
+func report(pass *analysis.Pass, node ast.Node, kind string, g *group, handled map[string]bool) {
+	var missing []string
+	for _, code := range g.codes {
+		if !handled[code] {
+			missing = append(missing, code)
+		}
+	}
+	if len(missing) > 0 {
+		pass.Reportf(node.Pos(), "missing cases in %s of %s: %s",
+			kind, groupName(pass, g.obj), strings.Join(missing, ", "))
+	}
+}
+
+func groupName(pass *analysis.Pass, obj types.Object) string {
+	if obj.Pkg() == pass.Pkg {
+		return obj.Name()
+	}
+	return obj.Pkg().Name() + "." + obj.Name()
+}
+
+// groupMethod returns the CodeGroup variable whose method name is called.
+func groupMethod(pass *analysis.Pass, call *ast.CallExpr, name string) types.Object {
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != name {
+		return nil
+	}
+	fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != errorsPath {
+		return nil
+	}
+	return groupVar(pass, sel.X)
+}
+
+// groupVar returns the variable of expr if it is a CodeGroup.
+func groupVar(pass *analysis.Pass, expr ast.Expr) types.Object {
+	var id *ast.Ident
+	switch x := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		id = x
+	case *ast.SelectorExpr:
+		id = x.Sel
+	default:
+		return nil
+	}
+	obj, ok := pass.TypesInfo.Uses[id].(*types.Var)
+	if !ok || !pass.ImportObjectFact(obj, new(groupFact)) {
+		return nil
+	}
+	return obj
+}
+
+func isErrorsFunc(pass *analysis.Pass, call *ast.CallExpr, name string) bool {
+	fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	return ok && fn.Pkg() != nil && fn.Pkg().Path() == errorsPath && fn.Name() == name &&
+		fn.Type().(*types.Signature).Recv() == nil
+}
+
+func stringConst(pass *analysis.Pass, expr ast.Expr) (string, bool) {
+	tv, ok := pass.TypesInfo.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
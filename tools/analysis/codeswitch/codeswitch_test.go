@@ -0,0 +1,34 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codeswitch_test
+
+import (
+	"testing"
+
+	"github.com/nextf/errors/tools/analysis/codeswitch"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), codeswitch.Analyzer, "a", "b")
+}
+
+func TestDefaultSignifiesExhaustive(t *testing.T) {
+	if err := codeswitch.Analyzer.Flags.Set("default-signifies-exhaustive", "true"); err != nil {
+		t.Fatal(err)
+	}
+	defer codeswitch.Analyzer.Flags.Set("default-signifies-exhaustive", "false")
+	analysistest.Run(t, analysistest.TestData(), codeswitch.Analyzer, "c")
+}
@@ -0,0 +1,81 @@
+package a
+
+import (
+	"b"
+
+	"github.com/nextf/errors"
+)
+
+var localCodes = errors.NewCodeGroup("L1", "L2") // want localCodes:`codes\(L1, L2\)`
+
+func switches(err error) {
+	code, _ := errors.GetCode(err)
+	switch code { // want `missing cases in switch of b.OrderCodes: TO_TEC_Db`
+	case "NF_BIS_Order":
+	case b.CodeDenied:
+	default:
+	}
+
+	switch code {
+	case "NF_BIS_Order", "AD_TEC_Db", "TO_TEC_Db":
+	}
+
+	// Both groups contain AD_TEC_Db.
+	switch code {
+	case "AD_TEC_Db":
+	}
+
+	switch code { // want `missing cases in switch of b.PaymentCodes: AD_TEC_Db`
+	case "NF_BIS_Payment":
+	}
+
+	// Not a code of a single group.
+	switch code {
+	case "NF_BIS_Order", "NF_BIS_Payment":
+	}
+
+	switch code, _ := b.OrderCodes.Of(err); code { // want `missing cases in switch of b.OrderCodes: NF_BIS_Order, AD_TEC_Db, TO_TEC_Db`
+	}
+
+	local, ok := localCodes.Of(err)
+	if ok {
+		switch local { // want `missing cases in switch of localCodes: L2`
+		case "L1":
+		}
+	}
+
+	other := "L1"
+	switch other {
+	case "L1":
+	}
+
+	reassigned, _ := errors.GetCode(err)
+	reassigned = "L1"
+	switch reassigned {
+	case "L1":
+	}
+}
+
+func cascades(err error, other error) {
+	if errors.Match(err, "NF_BIS_Order") { // want `missing cases in Match cascade of b.OrderCodes: TO_TEC_Db`
+	} else if errors.Match(err, "AD_TEC_Db") {
+	} else {
+	}
+
+	if errors.Match(err, "NF_BIS_Order") || errors.Match(err, "AD_TEC_Db") {
+	} else if errors.Match(err, "TO_TEC_Db") {
+	}
+
+	if errors.Match(err, "L1") {
+	} else if errors.Match(other, "L2") {
+	}
+
+	if errors.Match(err, "NF_BIS_Order") {
+	}
+
+	func() {
+		if errors.Match(err, "NF_BIS_Order") { // want `missing cases in Match cascade of b.OrderCodes: AD_TEC_Db`
+		} else if errors.Match(err, "TO_TEC_Db") {
+		}
+	}()
+}
@@ -0,0 +1,13 @@
+package b
+
+import "github.com/nextf/errors"
+
+const CodeDenied = "AD_TEC_Db"
+
+var OrderCodes = errors.NewCodeGroup("NF_BIS_Order", CodeDenied, "TO_TEC_Db") // want OrderCodes:`codes\(NF_BIS_Order, AD_TEC_Db, TO_TEC_Db\)`
+
+var PaymentCodes = errors.NewCodeGroup("NF_BIS_Payment", "AD_TEC_Db") // want PaymentCodes:`codes\(NF_BIS_Payment, AD_TEC_Db\)`
+
+var dynamic = "X"
+
+var NotAGroup = errors.NewCodeGroup("X", dynamic)
@@ -0,0 +1,26 @@
+package c
+
+import "github.com/nextf/errors"
+
+var codes = errors.NewCodeGroup("C1", "C2", "C3") // want codes:`codes\(C1, C2, C3\)`
+
+func handle(err error) {
+	code, _ := errors.GetCode(err)
+	switch code {
+	case "C1":
+	default:
+	}
+
+	switch code { // want `missing cases in switch of codes: C2, C3`
+	case "C1":
+	}
+
+	if errors.Match(err, "C1") {
+	} else if errors.Match(err, "C2") {
+	} else {
+	}
+
+	if errors.Match(err, "C1") { // want `missing cases in Match cascade of codes: C3`
+	} else if errors.Match(err, "C2") {
+	}
+}
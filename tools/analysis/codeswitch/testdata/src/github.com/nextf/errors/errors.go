@@ -0,0 +1,10 @@
+// Package errors is a stub of github.com/nextf/errors for the analyzer tests.
+package errors
+
+type CodeGroup struct{}
+
+func NewCodeGroup(codes ...string) *CodeGroup     { return nil }
+func (g *CodeGroup) Of(err error) (string, bool)  { return "", false }
+func (g *CodeGroup) MatchString(code string) bool { return false }
+func GetCode(err error) (string, bool)            { return "", false }
+func Match(err error, key interface{}) bool       { return false }
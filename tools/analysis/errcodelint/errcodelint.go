@@ -0,0 +1,298 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package errcodelint defines an Analyzer that reports common mistakes
+// in the use of error codes from github.com/nextf/errors.
+//
+// The following problems are reported:
+//
+//   - an error code literal that does not match [A-Za-z0-9_-]+;
+//   - a ConstError string without a leading [CODE];
+//   - the same code declared with different messages in one package;
+//   - Wrap or Wrapf applied to an error that already has a call stack,
+//     where WrapNodup or WrapNodupf would avoid recording it twice;
+//   - an Errorf format containing %w, which Errorf can not wrap.
+package errcodelint
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"regexp"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const errorsPath = "github.com/nextf/errors"
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "errcodelint",
+	Doc:      "check the use of error codes from github.com/nextf/errors",
+	URL:      "https://pkg.go.dev/github.com/nextf/errors/tools/analysis/errcodelint",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+var (
+	regForCode      = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	regForConstCode = regexp.MustCompile(`^\s*\[([A-Za-z0-9_-]+)\]\s*(.*)$`)
+)
+
+// codeArgs maps the functions taking an error code to the index of the code
+// parameter, and the index of the message parameter or -1 if the message is
+// formatted.
+var codeArgs = map[string][2]int{
+	"Close":             {2, -1},
+	"ErrCode":           {0, 1},
+	"ErrCodef":          {0, -1},
+	"PayloadErrCode":    {0, 1},
+	"TraceableErrCode":  {0, 1},
+	"TraceableErrCodef": {0, -1},
+	"WithErrCode":       {1, 2},
+	"WithErrCodef":      {1, -1},
+	"WithPayload":       {1, 2},
+	"Wrap":              {1, 2},
+	"Wrapf":             {1, -1},
+	"WrapNodup":         {1, 2},
+	"WrapNodupf":        {1, -1},
+	"WrapDeferred":      {1, -1},
+	"WrapNodupDeferred": {1, -1},
+}
+
+// tracers are the functions whose result always carries a call stack.
+var tracers = map[string]bool{
+	"AnnotateNodup":     true,
+	"AnnotateNodupf":    true,
+	"Trace":             true,
+	"TraceNodup":        true,
+	"TraceableErrCode":  true,
+	"TraceableErrCodef": true,
+	"Wrap":              true,
+	"Wrapf":             true,
+	"WrapNodup":         true,
+	"WrapNodupf":        true,
+}
+
+// declaration is the first message seen for a code declared in the package.
+type declaration struct {
+	pos     token.Pos
+	message string
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	declared := make(map[string]declaration)
+	declare := func(pos token.Pos, code, message string) {
+		if prev, ok := declared[code]; !ok {
+			declared[code] = declaration{pos, message}
+		} else if prev.message != message {
+			pass.Reportf(pos, "error code %s is already declared with message %q at %s",
+				code, prev.message, pass.Fset.Position(prev.pos))
+		}
+	}
+
+	nodeFilter := []ast.Node{
+		(*ast.FuncDecl)(nil),
+		(*ast.FuncLit)(nil),
+		(*ast.ValueSpec)(nil),
+		(*ast.CallExpr)(nil),
+	}
+	// The traced variables of each enclosing function, innermost last.
+	var scopes []map[types.Object]bool
+	inspect.Nodes(nodeFilter, func(n ast.Node, push bool) bool {
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			if n.Body == nil {
+				return false
+			}
+			if push {
+				scopes = append(scopes, tracedVars(pass, n.Body))
+			} else {
+				scopes = scopes[:len(scopes)-1]
+			}
+		case *ast.FuncLit:
+			if push {
+				scopes = append(scopes, tracedVars(pass, n.Body))
+			} else {
+				scopes = scopes[:len(scopes)-1]
+			}
+		case *ast.ValueSpec:
+			if push {
+				checkConstSpec(pass, n, declare)
+			}
+		case *ast.CallExpr:
+			if push {
+				var traced map[types.Object]bool
+				if len(scopes) > 0 {
+					traced = scopes[len(scopes)-1]
+				}
+				checkCall(pass, n, traced, declare)
+			}
+		}
+		return true
+	})
+	return nil, nil
+}
+
+// checkConstSpec checks declarations such as
+//
+//	const ErrX errors.ConstError = "[CODE] message"
+func checkConstSpec(pass *analysis.Pass, spec *ast.ValueSpec, declare func(token.Pos, string, string)) {
+	if spec.Type == nil || !isConstError(pass.TypesInfo.TypeOf(spec.Type)) {
+		return
+	}
+	for _, value := range spec.Values {
+		if _, ok := value.(*ast.CallExpr); ok {
+			// Reported as a conversion.
+			continue
+		}
+		checkConstError(pass, value, declare)
+	}
+}
+
+func checkCall(pass *analysis.Pass, call *ast.CallExpr, traced map[types.Object]bool, declare func(token.Pos, string, string)) {
+	if tv, ok := pass.TypesInfo.Types[call.Fun]; ok && tv.IsType() {
+		if isConstError(tv.Type) && len(call.Args) == 1 {
+			checkConstError(pass, call.Args[0], declare)
+		}
+		return
+	}
+	fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != errorsPath {
+		return
+	}
+	name := fn.Name()
+	if name == "Errorf" && len(call.Args) > 0 {
+		if format, ok := stringConst(pass, call.Args[0]); ok && strings.Contains(format, "%w") {
+			pass.Reportf(call.Args[0].Pos(), "errors.Errorf does not support %%w, use errors.Wrapf or errors.WithErrCodef to keep the cause")
+		}
+		return
+	}
+	args, ok := codeArgs[name]
+	if !ok || len(call.Args) <= args[0] {
+		return
+	}
+	code, ok := stringConst(pass, call.Args[args[0]])
+	if ok && !regForCode.MatchString(code) {
+		pass.Reportf(call.Args[args[0]].Pos(), "invalid error code %q, want [A-Za-z0-9_-]+", code)
+		ok = false
+	}
+	if ok && args[1] >= 0 && args[0] == 0 {
+		if message, isConst := stringConst(pass, call.Args[args[1]]); isConst {
+			declare(call.Pos(), code, message)
+		}
+	}
+	if (name == "Wrap" || name == "Wrapf") && isTraced(pass, call.Args[0], traced) {
+		pass.Reportf(call.Pos(), "%s records a second call stack on an error that already has one, use %s", name, strings.Replace(name, "Wrap", "WrapNodup", 1))
+	}
+}
+
+func checkConstError(pass *analysis.Pass, expr ast.Expr, declare func(token.Pos, string, string)) {
+	s, ok := stringConst(pass, expr)
+	if !ok {
+		return
+	}
+	group := regForConstCode.FindStringSubmatch(s)
+	if group == nil {
+		pass.Reportf(expr.Pos(), "ConstError %q has no error code, want \"[CODE] message\"", s)
+		return
+	}
+	declare(expr.Pos(), group[1], strings.TrimSpace(group[2]))
+}
+
+// tracedVars collects the variables declared in body that are only ever
+// assigned the result of a call recording a call stack.
+func tracedVars(pass *analysis.Pass, body *ast.BlockStmt) map[types.Object]bool {
+	traced := make(map[types.Object]bool)
+	assign := func(lhs ast.Expr, rhs ast.Expr) {
+		id, ok := lhs.(*ast.Ident)
+		if !ok {
+			return
+		}
+		obj := pass.TypesInfo.ObjectOf(id)
+		if obj == nil || obj.Pos() < body.Pos() || obj.Pos() >= body.End() {
+			// Parameters and outer variables may hold anything before
+			// they are assigned here.
+			return
+		}
+		prev, seen := traced[obj]
+		traced[obj] = (!seen || prev) && rhs != nil && isTracer(pass, rhs)
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range n.Lhs {
+				var rhs ast.Expr
+				if len(n.Lhs) == len(n.Rhs) {
+					rhs = n.Rhs[i]
+				}
+				assign(lhs, rhs)
+			}
+		case *ast.ValueSpec:
+			for i, name := range n.Names {
+				var rhs ast.Expr
+				if len(n.Names) == len(n.Values) {
+					rhs = n.Values[i]
+				}
+				assign(name, rhs)
+			}
+		case *ast.UnaryExpr:
+			if n.Op == token.AND {
+				// The variable may be modified through its address.
+				assign(n.X, nil)
+			}
+		}
+		return true
+	})
+	return traced
+}
+
+func isTraced(pass *analysis.Pass, expr ast.Expr, traced map[types.Object]bool) bool {
+	expr = ast.Unparen(expr)
+	if id, ok := expr.(*ast.Ident); ok {
+		return traced[pass.TypesInfo.ObjectOf(id)]
+	}
+	return isTracer(pass, expr)
+}
+
+func isTracer(pass *analysis.Pass, expr ast.Expr) bool {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	return ok && fn.Pkg() != nil && fn.Pkg().Path() == errorsPath && tracers[fn.Name()]
+}
+
+func isConstError(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == errorsPath && obj.Name() == "ConstError"
+}
+
+func stringConst(pass *analysis.Pass, expr ast.Expr) (string, bool) {
+	tv, ok := pass.TypesInfo.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
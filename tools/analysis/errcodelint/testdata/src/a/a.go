@@ -0,0 +1,61 @@
+package a
+
+import (
+	"io"
+
+	"github.com/nextf/errors"
+)
+
+const (
+	ErrNotFound  = errors.ConstError("[NF_Order] Not found orders")
+	ErrNoCode    = errors.ConstError("Not found orders")            // want `ConstError "Not found orders" has no error code`
+	ErrBadCode   = errors.ConstError("[NF Order] Not found orders") // want `has no error code`
+	ErrDifferent = errors.ConstError("[NF_Order] Orders not found") // want `error code NF_Order is already declared with message "Not found orders"`
+	ErrSame      = errors.ConstError("[NF_Order]  Not found orders ")
+)
+
+const ErrTyped errors.ConstError = "Typed" // want `ConstError "Typed" has no error code`
+
+var (
+	ErrDeny      = errors.ErrCode("AD_Db", "Access denied")
+	ErrDenyAgain = errors.TraceableErrCode("AD_Db", "Denied") // want `error code AD_Db is already declared`
+	ErrSpace     = errors.ErrCode("AD Db", "Access denied")   // want `invalid error code "AD Db"`
+	ErrEmpty     = errors.ErrCodef("", "Access denied")       // want `invalid error code ""`
+)
+
+func codes(err error, code string) {
+	_ = errors.Wrap(err, "IO.Read", "read failed")        // want `invalid error code "IO.Read"`
+	_ = errors.WithErrCodef(err, "IO/Read", "read %d", 1) // want `invalid error code "IO/Read"`
+	_ = errors.Wrap(err, code, "read failed")
+	_ = errors.WithErrCode(err, "NF_Order", "other message")
+	_ = errors.PayloadErrCode("BIS Invalid", "invalid", 1)            // want `invalid error code "BIS Invalid"`
+	_ = errors.WithPayload(err, "BIS.Invalid", "invalid", []string{}) // want `invalid error code "BIS.Invalid"`
+}
+
+func errorf(err error) {
+	_ = errors.Errorf("[IO_Read] read failed: %w", err) // want `errors.Errorf does not support %w`
+	_ = errors.Errorf("[IO_Read] read failed: %v", err)
+}
+
+func wrapTraced(err error) error {
+	_ = errors.Wrap(errors.Trace(err), "L1", "level 1") // want `Wrap records a second call stack on an error that already has one, use WrapNodup`
+	_ = errors.WrapNodup(errors.Trace(err), "L1", "level 1")
+
+	traced := errors.Wrap(err, "L1", "level 1")
+	_ = errors.Wrapf(traced, "L2", "level %d", 2) // want `use WrapNodupf`
+
+	maybe := errors.Trace(err)
+	if maybe != nil {
+		maybe = io.EOF
+	}
+	_ = errors.Wrap(maybe, "L2", "level 2")
+
+	err = errors.Trace(err)
+	_ = errors.Wrap(err, "L2", "level 2")
+
+	func() {
+		inner := errors.TraceableErrCode("L0", "level 0")
+		_ = errors.Wrap(inner, "L1", "level 1") // want `use WrapNodup`
+	}()
+	return errors.Wrap(traced, "L3", "level 3") // want `use WrapNodup`
+}
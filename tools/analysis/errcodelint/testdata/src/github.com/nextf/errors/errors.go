@@ -0,0 +1,31 @@
+// Package errors is a stub of github.com/nextf/errors for the analyzer tests.
+package errors
+
+type ConstError string
+
+func (e ConstError) Error() string { return string(e) }
+
+func New(message string) error                                { return nil }
+func Errorf(format string, args ...interface{}) error         { return nil }
+func ErrCode(code, message string) error                      { return nil }
+func ErrCodef(code, format string, args ...interface{}) error { return nil }
+func TraceableErrCode(code, message string) error             { return nil }
+func TraceableErrCodef(code, format string, args ...interface{}) error {
+	return nil
+}
+func WithErrCode(err error, code, message string) error { return nil }
+func WithErrCodef(err error, code, format string, args ...interface{}) error {
+	return nil
+}
+func Trace(err error) error                           { return nil }
+func TraceNodup(err error) error                      { return nil }
+func Wrap(err error, code, message string) error      { return nil }
+func WrapNodup(err error, code, message string) error { return nil }
+func Wrapf(err error, code, format string, args ...interface{}) error {
+	return nil
+}
+func WrapNodupf(err error, code, format string, args ...interface{}) error {
+	return nil
+}
+func PayloadErrCode[T any](code, message string, payload T) error         { return nil }
+func WithPayload[T any](err error, code, message string, payload T) error { return nil }
@@ -0,0 +1,29 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The errcodelint command checks the use of error codes from
+// github.com/nextf/errors with the errcodelint and codeswitch analyzers.
+// It is run by go vet:
+//
+//	go install github.com/nextf/errors/tools/cmd/errcodelint@latest
+//	go vet -vettool=$(which errcodelint) ./...
+package main
+
+import (
+	"github.com/nextf/errors/tools/analysis/codeswitch"
+	"github.com/nextf/errors/tools/analysis/errcodelint"
+	"golang.org/x/tools/go/analysis/unitchecker"
+)
+
+func main() { unitchecker.Main(errcodelint.Analyzer, codeswitch.Analyzer) }
@@ -0,0 +1,168 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines around the changes of a hunk.
+const diffContext = 3
+
+// edit is a line of a diff: an unchanged line ' ', a line of a deleted '-'
+// or a line of b inserted '+'. a and b are the indexes of the next lines of
+// a and b.
+type edit struct {
+	op   byte
+	a, b int
+}
+
+// diffBytes returns the unified diff of a and b, like diff -u.
+func diffBytes(filename string, a, b []byte) []byte {
+	x, y := splitLines(a), splitLines(b)
+	edits := diffLines(x, y)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "--- %s.orig\n+++ %s\n", filename, filename)
+	for i := 0; i < len(edits); {
+		if edits[i].op == ' ' {
+			i++
+			continue
+		}
+		// The hunk extends to the last change followed by fewer than
+		// 2*diffContext unchanged lines.
+		start, end := max(i-diffContext, 0), i
+		for j := i; j < len(edits) && j-end <= 2*diffContext; j++ {
+			if edits[j].op != ' ' {
+				end = j + 1
+			}
+		}
+		end = min(end+diffContext, len(edits))
+		writeHunk(&buf, x, y, edits[start:end])
+		i = end
+	}
+	return buf.Bytes()
+}
+
+func writeHunk(buf *bytes.Buffer, a, b []string, edits []edit) {
+	var na, nb int
+	for _, e := range edits {
+		if e.op != '+' {
+			na++
+		}
+		if e.op != '-' {
+			nb++
+		}
+	}
+	fmt.Fprintf(buf, "@@ -%s +%s @@\n", hunkRange(edits[0].a, na), hunkRange(edits[0].b, nb))
+	for _, e := range edits {
+		line := ""
+		switch e.op {
+		case ' ', '-':
+			line = a[e.a]
+		case '+':
+			line = b[e.b]
+		}
+		buf.WriteByte(e.op)
+		buf.WriteString(line)
+		if !strings.HasSuffix(line, "\n") {
+			buf.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange formats the range of n lines from the line at index start.
+func hunkRange(start, n int) string {
+	switch n {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
+
+// splitLines splits s into lines, keeping their newline.
+func splitLines(s []byte) []string {
+	lines := strings.SplitAfter(string(s), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the shortest edit script from a to b, computed with the
+// algorithm of Myers, in O((len(a)+len(b))*D) for D edits.
+func diffLines(a, b []string) []edit {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	// trace holds v before each step d.
+	var trace [][]int
+search:
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+	// Walk the trace back from the end of a and b.
+	var edits []edit
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		prev := k - 1
+		if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+			prev = k + 1
+		}
+		prevX := v[offset+prev]
+		prevY := prevX - prev
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, edit{' ', x, y})
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			y--
+			edits = append(edits, edit{'+', x, y})
+		} else {
+			x--
+			edits = append(edits, edit{'-', x, y})
+		}
+	}
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
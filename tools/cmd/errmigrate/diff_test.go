@@ -0,0 +1,49 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "testing"
+
+func TestDiffBytes(t *testing.T) {
+	a := "package p\n\nimport \"github.com/pkg/errors\"\n\nfunc f() {}\n\nfunc g() {}\n\nfunc h() {}\n\nfunc i() {}\n\nvar err = errors.New(\"x\")\n"
+	b := "package p\n\nimport \"github.com/nextf/errors\"\n\nfunc f() {}\n\nfunc g() {}\n\nfunc h() {}\n\nfunc i() {}\n\nvar err = errors.Trace(errors.New(\"x\"))\n"
+	want := `--- f.go.orig
++++ f.go
+@@ -1,6 +1,6 @@
+ package p
+ 
+-import "github.com/pkg/errors"
++import "github.com/nextf/errors"
+ 
+ func f() {}
+ 
+@@ -10,4 +10,4 @@
+ 
+ func i() {}
+ 
+-var err = errors.New("x")
++var err = errors.Trace(errors.New("x"))
+`
+	if got := string(diffBytes("f.go", []byte(a), []byte(b))); got != want {
+		t.Errorf("Expect\n%s\ngot\n%s", want, got)
+	}
+	want = "--- f.go.orig\n+++ f.go\n@@ -1 +1,2 @@\n-a\n\\ No newline at end of file\n+a\n+b\n"
+	if got := string(diffBytes("f.go", []byte("a"), []byte("a\nb\n"))); got != want {
+		t.Errorf("Expect\n%s\ngot\n%s", want, got)
+	}
+	if got := string(diffBytes("f.go", []byte(a), []byte(a))); got != "--- f.go.orig\n+++ f.go\n" {
+		t.Errorf("Expect no hunk, got\n%s", got)
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The errmigrate command rewrites Go source files using github.com/pkg/errors
+// and fmt.Errorf with %w to use github.com/nextf/errors instead.
+//
+// Usage:
+//
+//	errmigrate [flags] [path ...]
+//
+// The calls are rewritten as follows:
+//
+//	errors.Wrap(err, msg)               errors.Wrap(err, CODE, msg)
+//	errors.Wrapf(err, format, args...)  errors.Wrapf(err, CODE, format, args...)
+//	errors.WithStack(err)               errors.Trace(err)
+//	errors.WithMessage(err, msg)        errors.Annotate(err, msg)
+//	errors.WithMessagef(err, format)    errors.Annotatef(err, format)
+//	errors.New(msg)                     errors.Trace(errors.New(msg))
+//	errors.Errorf(format, args...)      errors.Trace(errors.Errorf(format, args...))
+//	errors.Cause(err)                   errors.Cause(err)
+//	fmt.Errorf("read %s: %w", f, err)   errors.Wrapf(err, CODE, "read %s", f)
+//
+// CODE is the value of the -code flag. With -infer, the name of the enclosing
+// function is appended to it, so that -code IO_TEC -infer gives IO_TEC_ReadFile
+// in func ReadFile.
+//
+// Calls that can not be migrated are reported on standard error.
+//
+// The flags are:
+//
+//	-d
+//		Do not rewrite files, print diffs instead.
+//	-l
+//		Do not print the rewritten source, list the files that would change.
+//	-w
+//		Write the result to the source file instead of standard output.
+//	-code string
+//		The error code inserted where one is required. (default "TODO")
+//	-infer
+//		Append the name of the enclosing function to the error code.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	list  = flag.Bool("l", false, "list files whose source would change")
+	write = flag.Bool("w", false, "write result to the source file instead of stdout")
+	diff  = flag.Bool("d", false, "display diffs instead of rewriting files")
+	code  = flag.String("code", "TODO", "error code inserted where one is required")
+	infer = flag.Bool("infer", false, "append the name of the enclosing function to the error code")
+)
+
+var exitCode = 0
+
+func report(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	exitCode = 2
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: errmigrate [flags] [path ...]\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	m := &migrator{
+		code:  *code,
+		infer: *infer,
+		warn: func(pos token.Position, format string, args ...interface{}) {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", pos, fmt.Sprintf(format, args...))
+		},
+	}
+	if flag.NArg() == 0 {
+		if *write {
+			report(fmt.Errorf("errmigrate: can not use -w with standard input"))
+		} else if err := processFile(m, "<standard input>", os.Stdin); err != nil {
+			report(err)
+		}
+		os.Exit(exitCode)
+	}
+	for _, path := range flag.Args() {
+		info, err := os.Stat(path)
+		if err != nil {
+			report(err)
+			continue
+		}
+		if !info.IsDir() {
+			if err := processFile(m, path, nil); err != nil {
+				report(err)
+			}
+			continue
+		}
+		err = filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if name := d.Name(); path != "." && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(path, ".go") {
+				if err := processFile(m, path, nil); err != nil {
+					report(err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			report(err)
+		}
+	}
+	os.Exit(exitCode)
+}
+
+func processFile(m *migrator, filename string, in *os.File) error {
+	var src []byte
+	var err error
+	if in != nil {
+		src, err = readAll(in)
+	} else {
+		src, err = os.ReadFile(filename)
+	}
+	if err != nil {
+		return err
+	}
+	res, changed, err := m.migrate(filename, src)
+	if err != nil {
+		return err
+	}
+	if changed {
+		if *list {
+			fmt.Println(filename)
+		}
+		if *write {
+			info, err := os.Stat(filename)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(filename, res, info.Mode().Perm()); err != nil {
+				return err
+			}
+		}
+		if *diff {
+			os.Stdout.Write(diffBytes(filename, src, res))
+		}
+	}
+	if !*list && !*write && !*diff {
+		_, err = os.Stdout.Write(res)
+	}
+	return err
+}
+
+func readAll(f *os.File) ([]byte, error) {
+	var buf bytes.Buffer
+	_, err := buf.ReadFrom(f)
+	return buf.Bytes(), err
+}
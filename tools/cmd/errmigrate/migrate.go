@@ -0,0 +1,314 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/imports"
+)
+
+const (
+	errorsPath    = "github.com/nextf/errors"
+	pkgErrorsPath = "github.com/pkg/errors"
+)
+
+// regForWrapVerb matches a format ending with a wrapped error, such as
+// "read %s: %w".
+var regForWrapVerb = regexp.MustCompile(`^(.*?)(?::\s*|\s+)?%w$`)
+
+var regForInvalidCodeChar = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+type migrator struct {
+	// code is the error code inserted where one is required.
+	code string
+	// infer appends the name of the enclosing function to code.
+	infer bool
+	// warn reports a call that can not be migrated.
+	warn func(pos token.Position, format string, args ...interface{})
+}
+
+// migrate rewrites the calls to github.com/pkg/errors and the fmt.Errorf
+// calls wrapping an error in src, and reports whether anything changed.
+func (m *migrator) migrate(filename string, src []byte) ([]byte, bool, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, false, err
+	}
+	pkgName, hasPkgErrors := importName(file, pkgErrorsPath, "errors")
+	fmtName, _ := importName(file, "fmt", "fmt")
+	name := pkgName
+	if _, ok := importName(file, errorsPath, "errors"); ok {
+		name, _ = importName(file, errorsPath, "errors")
+	} else if !hasPkgErrors {
+		name = "errors"
+		if _, ok := importName(file, "errors", "errors"); ok {
+			name = "nerrors"
+		}
+	}
+
+	changed := false
+	used := false
+	// The selectors of pkg/errors that refer to a name of this package
+	// once the import is rewritten.
+	migrated := make(map[*ast.SelectorExpr]bool)
+	unmigrated := false
+	var funcName string
+	astutil.Apply(file, func(c *astutil.Cursor) bool {
+		switch n := c.Node().(type) {
+		case *ast.FuncDecl:
+			funcName = n.Name.Name
+			if n.Recv != nil && len(n.Recv.List) == 1 && recvName(n.Recv.List[0].Type) != "" {
+				funcName = recvName(n.Recv.List[0].Type) + "_" + funcName
+			}
+		case *ast.CallExpr:
+			sel, ok := n.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			x, ok := sel.X.(*ast.Ident)
+			if !ok || x.Obj != nil {
+				// Not a package name.
+				return true
+			}
+			code := m.code
+			if m.infer && funcName != "" {
+				code += "_" + regForInvalidCodeChar.ReplaceAllString(funcName, "_")
+			}
+			pos := fset.Position(n.Pos())
+			switch {
+			case hasPkgErrors && x.Name == pkgName:
+				if m.rewritePkgErrors(n, sel, code) {
+					migrated[sel] = true
+					changed = true
+					if sel.Sel.Name == "New" || sel.Sel.Name == "Errorf" {
+						if len(n.Args) > 0 && hasCodePrefix(n.Args[0]) {
+							m.warn(pos, "errors.%s: the text in brackets becomes the error code", sel.Sel.Name)
+						}
+						// The errors of pkg/errors record a call stack,
+						// ConstError does not.
+						c.Replace(&ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(pkgName), Sel: ast.NewIdent("Trace")}, Args: []ast.Expr{n}})
+					}
+				}
+			case fmtName != "" && x.Name == fmtName && sel.Sel.Name == "Errorf":
+				if call := m.rewriteErrorf(pos, n, name, code); call != nil {
+					c.Replace(call)
+					changed, used = true, true
+				}
+			}
+		case *ast.SelectorExpr:
+			// Any other use of pkg/errors, such as errors.StackTrace or
+			// a function value, does not compile once the import is
+			// rewritten.
+			if x, ok := n.X.(*ast.Ident); ok && x.Obj == nil && hasPkgErrors && x.Name == pkgName && !migrated[n] {
+				m.warn(fset.Position(n.Pos()), "errors.%s has no equivalent in %s", n.Sel.Name, errorsPath)
+				unmigrated = true
+			}
+		}
+		return true
+	}, func(c *astutil.Cursor) bool {
+		if _, ok := c.Node().(*ast.FuncDecl); ok {
+			funcName = ""
+		}
+		return true
+	})
+
+	if unmigrated {
+		for _, spec := range file.Imports {
+			if p, _ := strconv.Unquote(spec.Path.Value); p == pkgErrorsPath {
+				m.warn(fset.Position(spec.Pos()), "%s is still used, file not migrated", pkgErrorsPath)
+			}
+		}
+		return src, false, nil
+	}
+	if hasPkgErrors {
+		changed = astutil.RewriteImport(fset, file, pkgErrorsPath, errorsPath) || changed
+	} else if used {
+		if name == "errors" {
+			astutil.AddImport(fset, file, errorsPath)
+		} else {
+			astutil.AddNamedImport(fset, file, name, errorsPath)
+		}
+	}
+	if fmtName != "" && !astutil.UsesImport(file, "fmt") {
+		astutil.DeleteImport(fset, file, "fmt")
+	}
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT && len(gen.Specs) == 1 {
+			gen.Lparen, gen.Rparen = token.NoPos, token.NoPos
+		}
+	}
+	if !changed {
+		return src, false, nil
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return nil, false, err
+	}
+	// Separate the standard library imports from the others.
+	res, err := imports.Process(filename, buf.Bytes(), &imports.Options{
+		Comments:   true,
+		TabIndent:  true,
+		TabWidth:   8,
+		FormatOnly: true,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return res, true, nil
+}
+
+// rewritePkgErrors rewrites a call to github.com/pkg/errors in place, and
+// reports whether it has an equivalent.
+func (m *migrator) rewritePkgErrors(call *ast.CallExpr, sel *ast.SelectorExpr, code string) bool {
+	codeLit := &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(code)}
+	switch sel.Sel.Name {
+	case "Wrap", "Wrapf":
+		if len(call.Args) < 2 {
+			return false
+		}
+		call.Args = append(call.Args[:1], append([]ast.Expr{codeLit}, call.Args[1:]...)...)
+	case "WithMessage", "WithMessagef":
+		sel.Sel.Name = strings.Replace(sel.Sel.Name, "WithMessage", "Annotate", 1)
+	case "WithStack":
+		sel.Sel.Name = "Trace"
+	case "New", "Errorf":
+		// Same signature, traced by the caller.
+	case "Cause", "Is", "As", "Unwrap":
+		// Same signature.
+	default:
+		return false
+	}
+	return true
+}
+
+// hasCodePrefix reports whether expr is a string literal starting with text
+// in brackets, which New and Errorf parse as an error code.
+func hasCodePrefix(expr ast.Expr) bool {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return err == nil && strings.HasPrefix(strings.TrimSpace(s), "[")
+}
+
+// rewriteErrorf returns the equivalent of a fmt.Errorf call ending with %w,
+// or nil if there is none.
+func (m *migrator) rewriteErrorf(pos token.Position, call *ast.CallExpr, name, code string) ast.Expr {
+	if len(call.Args) < 2 || call.Ellipsis.IsValid() {
+		return nil
+	}
+	lit, ok := call.Args[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return nil
+	}
+	format, err := strconv.Unquote(lit.Value)
+	if err != nil || !strings.Contains(format, "%w") {
+		return nil
+	}
+	group := regForWrapVerb.FindStringSubmatch(format)
+	verbs := countVerbs(format)
+	if group == nil || verbs < 0 || strings.Count(format, "%w") != 1 || verbs != len(call.Args)-1 {
+		m.warn(pos, "fmt.Errorf(%s) can not be migrated, %%w must be the last verb", lit.Value)
+		return nil
+	}
+	message := group[1]
+	cause := call.Args[len(call.Args)-1]
+	args := call.Args[1 : len(call.Args)-1]
+	fun := func(name, fn string) ast.Expr {
+		return &ast.SelectorExpr{X: ast.NewIdent(name), Sel: ast.NewIdent(fn)}
+	}
+	if message == "" {
+		return &ast.CallExpr{Fun: fun(name, "Trace"), Args: []ast.Expr{cause}}
+	}
+	codeLit := &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(code)}
+	if len(args) == 0 {
+		message = strings.ReplaceAll(message, "%%", "%")
+		return &ast.CallExpr{
+			Fun:  fun(name, "Wrap"),
+			Args: []ast.Expr{cause, codeLit, &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(message)}},
+		}
+	}
+	return &ast.CallExpr{
+		Fun:  fun(name, "Wrapf"),
+		Args: append([]ast.Expr{cause, codeLit, &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(message)}}, args...),
+	}
+}
+
+// countVerbs returns the number of arguments consumed by format,
+// or -1 if it uses explicit argument indexes or * widths.
+func countVerbs(format string) int {
+	n := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		for ; i < len(format); i++ {
+			c := format[i]
+			if c == '[' || c == '*' {
+				return -1
+			}
+			if strings.IndexByte("+-# 0123456789.", c) < 0 {
+				break
+			}
+		}
+		if i < len(format) && format[i] != '%' {
+			n++
+		}
+	}
+	return n
+}
+
+// importName returns the name under which path is imported by file.
+func importName(file *ast.File, path, defaultName string) (string, bool) {
+	for _, spec := range file.Imports {
+		if p, _ := strconv.Unquote(spec.Path.Value); p != path {
+			continue
+		}
+		if spec.Name == nil {
+			return defaultName, true
+		}
+		if spec.Name.Name == "_" || spec.Name.Name == "." {
+			return "", false
+		}
+		return spec.Name.Name, true
+	}
+	return "", false
+}
+
+func recvName(expr ast.Expr) string {
+	switch x := expr.(type) {
+	case *ast.StarExpr:
+		return recvName(x.X)
+	case *ast.IndexExpr:
+		return recvName(x.X)
+	case *ast.IndexListExpr:
+		return recvName(x.X)
+	case *ast.Ident:
+		return x.Name
+	}
+	return ""
+}
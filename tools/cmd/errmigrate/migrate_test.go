@@ -0,0 +1,239 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		want  string
+		infer bool
+		warns int
+		// unchanged reports that the file is left unchanged.
+		unchanged bool
+	}{{
+		name: "pkg/errors",
+		src: `package p
+
+import "github.com/pkg/errors"
+
+func f(err error) error {
+	err = errors.Wrap(err, "read failed")
+	err = errors.Wrapf(err, "read %s", "x")
+	err = errors.WithMessage(err, "read failed")
+	err = errors.WithMessagef(err, "read %s", "x")
+	return errors.WithStack(errors.Cause(err))
+}
+`,
+		want: `package p
+
+import "github.com/nextf/errors"
+
+func f(err error) error {
+	err = errors.Wrap(err, "TODO", "read failed")
+	err = errors.Wrapf(err, "TODO", "read %s", "x")
+	err = errors.Annotate(err, "read failed")
+	err = errors.Annotatef(err, "read %s", "x")
+	return errors.Trace(errors.Cause(err))
+}
+`,
+	}, {
+		name: "pkg/errors New",
+		src: `package p
+
+import pkgerrors "github.com/pkg/errors"
+
+var errRead = pkgerrors.New("read failed")
+
+func f(name string) error {
+	return pkgerrors.Errorf("[%s] open", name)
+}
+`,
+		want: `package p
+
+import pkgerrors "github.com/nextf/errors"
+
+var errRead = pkgerrors.Trace(pkgerrors.New("read failed"))
+
+func f(name string) error {
+	return pkgerrors.Trace(pkgerrors.Errorf("[%s] open", name))
+}
+`,
+		warns: 1,
+	}, {
+		name: "fmt.Errorf",
+		src: `package p
+
+import "fmt"
+
+func (r *reader) read(err error) error {
+	if err != nil {
+		return fmt.Errorf("read %s: %w", "x", err)
+	}
+	return fmt.Errorf("100%% failed: %w", err)
+}
+`,
+		want: `package p
+
+import "github.com/nextf/errors"
+
+func (r *reader) read(err error) error {
+	if err != nil {
+		return errors.Wrapf(err, "TODO_reader_read", "read %s", "x")
+	}
+	return errors.Wrap(err, "TODO_reader_read", "100% failed")
+}
+`,
+		infer: true,
+	}, {
+		name: "std errors imported",
+		src: `package p
+
+import (
+	"errors"
+	"fmt"
+)
+
+var errRead = errors.New("read")
+
+func f(err error) error {
+	return fmt.Errorf("%w", err)
+}
+`,
+		want: `package p
+
+import (
+	"errors"
+
+	nerrors "github.com/nextf/errors"
+)
+
+var errRead = errors.New("read")
+
+func f(err error) error {
+	return nerrors.Trace(err)
+}
+`,
+	}, {
+		name: "not migrated",
+		src: `package p
+
+import (
+	"fmt"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+func f(err error) error {
+	var st pkgerrors.StackTrace
+	_ = st
+	wrap := pkgerrors.WithStack
+	_ = pkgerrors.Wrap(err, "read failed")
+	_ = fmt.Errorf("%w: read", err)
+	return wrap(err)
+}
+`,
+		warns:     4,
+		unchanged: true,
+	}, {
+		name: "fmt.Errorf not migrated",
+		src: `package p
+
+import "fmt"
+
+func f(err error) error {
+	_ = fmt.Errorf("%w: read", err)
+	return fmt.Errorf("read: %w", err)
+}
+`,
+		want: `package p
+
+import (
+	"fmt"
+
+	"github.com/nextf/errors"
+)
+
+func f(err error) error {
+	_ = fmt.Errorf("%w: read", err)
+	return errors.Wrap(err, "TODO", "read")
+}
+`,
+		warns: 1,
+	}}
+	for _, tt := range tests {
+		warns := 0
+		m := &migrator{
+			code:  "TODO",
+			infer: tt.infer,
+			warn: func(pos token.Position, format string, args ...interface{}) {
+				warns++
+			},
+		}
+		got, changed, err := m.migrate("p.go", []byte(tt.src))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if tt.unchanged {
+			tt.want = tt.src
+		}
+		if changed == tt.unchanged {
+			t.Errorf("%s: Expect changed=%v, got %v", tt.name, !tt.unchanged, changed)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: Expect\n%s\ngot\n%s", tt.name, tt.want, got)
+		}
+		if warns != tt.warns {
+			t.Errorf("%s: Expect %d warnings, got %d", tt.name, tt.warns, warns)
+		}
+	}
+}
+
+func TestMigrateUnchanged(t *testing.T) {
+	src := `package p
+
+import "fmt"
+
+func f(err error) error {
+	return fmt.Errorf("read %v", err)
+}
+`
+	m := &migrator{code: "TODO", warn: func(token.Position, string, ...interface{}) {}}
+	got, changed, err := m.migrate("p.go", []byte(src))
+	if err != nil || changed || string(got) != src {
+		t.Errorf("Expect unchanged source, got %v\n%s", err, got)
+	}
+}
+
+func TestCountVerbs(t *testing.T) {
+	for format, want := range map[string]int{
+		"read":                  0,
+		"100%% read %s":         1,
+		"%-8.3f %+v %w":         3,
+		"%[1]s":                 -1,
+		"%*d":                   -1,
+		strings.Repeat("%d", 5): 5,
+	} {
+		if got := countVerbs(format); got != want {
+			t.Errorf("countVerbs(%q): Expect %d, got %d", format, want, got)
+		}
+	}
+}
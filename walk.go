@@ -0,0 +1,192 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"iter"
+	"reflect"
+)
+
+// Walk calls fn for each error in err's chain, starting with err itself,
+// with the depth of the error in the chain, 0 for err. Walk stops when fn
+// returns false.
+//
+// The chain is walked depth-first: an error with a method Unwrap() []error,
+// such as the errors returned by errors.Join, is followed by each of its
+// errors and their chains in order. Errors with a method Unwrap() error are
+// followed by the error it returns, and errors with a method Cause() error
+// but no Unwrap method by their cause.
+//
+// Each pointer is visited once, so that Walk terminates on chains that refer
+// to an error already visited. Errors of other types are not compared and
+// may be visited more than once, such as a value joined twice. The errors
+// deeper than the maximum set by SetMaxChainDepth are not visited.
+func Walk(err error, fn func(link error, depth int) bool) {
+	walk(err, fn)
+}
+
+// All returns an iterator over the errors in err's chain, in the order of
+// Walk.
+//
+//	for link := range errors.All(err) {
+//		...
+//	}
+func All(err error) iter.Seq[error] {
+	return func(yield func(error) bool) {
+		walk(err, func(link error, _ int) bool {
+			return yield(link)
+		})
+	}
+}
+
+// maxVisitedScan is the number of visited errors beyond which walk indexes
+// them in a map rather than scanning them.
+const maxVisitedScan = 16
+
+type walkEntry struct {
+	err   error
+	depth int
+}
+
+// walk implements Walk, and reports whether fn did not stop it.
+func walk(err error, fn func(link error, depth int) bool) bool {
+	if err == nil {
+		return true
+	}
+	// Most walks stop at the first link, which cannot have been visited.
+	cause, joined := unwrap(err)
+	if joined {
+		return walkFrom(err, nil, fn)
+	}
+	if !fn(err, 0) {
+		return false
+	}
+	if cause == nil || maxChainDepth() <= 1 {
+		return true
+	}
+	return walkFrom(err, cause, fn)
+}
+
+// walkFrom walks err's chain from cause, the cause of err already visited,
+// or from err if cause is nil.
+func walkFrom(err, cause error, fn func(link error, depth int) bool) bool {
+	var (
+		maxDepth = maxChainDepth()
+		scanned  = make([]error, 0, maxVisitedScan)
+		link     = err
+		depth    = 0
+	)
+	if cause != nil {
+		if identifiable(err) {
+			scanned = append(scanned, err)
+		}
+		link, depth = cause, 1
+	}
+	// Most chains have no error with a method Unwrap() []error: their first
+	// links are walked without pending errors.
+	for ; depth < maxVisitedScan; depth++ {
+		cause, joined := unwrap(link)
+		if joined {
+			break
+		}
+		if identifiable(link) {
+			if visited(link, scanned, nil) {
+				return true
+			}
+			scanned = append(scanned, link)
+		}
+		if !fn(link, depth) {
+			return false
+		}
+		if depth+1 >= maxDepth || cause == nil {
+			return true
+		}
+		link = cause
+	}
+	var (
+		pending = append(make([]walkEntry, 0, 8), walkEntry{link, depth})
+		indexed map[error]struct{}
+	)
+	for len(pending) > 0 {
+		e := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if identifiable(e.err) {
+			if visited(e.err, scanned, indexed) {
+				continue
+			}
+			if len(scanned) < maxVisitedScan {
+				scanned = append(scanned, e.err)
+			} else {
+				if indexed == nil {
+					indexed = make(map[error]struct{})
+				}
+				indexed[e.err] = struct{}{}
+			}
+		}
+		if !fn(e.err, e.depth) {
+			return false
+		}
+		if e.depth+1 >= maxDepth {
+			continue
+		}
+		if x, ok := e.err.(interface{ Unwrap() []error }); ok {
+			errs := x.Unwrap()
+			for i := len(errs) - 1; i >= 0; i-- {
+				if errs[i] != nil {
+					pending = append(pending, walkEntry{errs[i], e.depth + 1})
+				}
+			}
+		} else if cause := next(e.err); cause != nil {
+			pending = append(pending, walkEntry{cause, e.depth + 1})
+		}
+	}
+	return true
+}
+
+// unwrap returns the cause of err as next does, with a single type switch,
+// or reports that err is joined: it has a method Unwrap() []error.
+func unwrap(err error) (cause error, joined bool) {
+	switch x := err.(type) {
+	case interface{ Unwrap() error }:
+		return x.Unwrap(), false
+	case interface{ Unwrap() []error }:
+		return nil, true
+	case interface{ Cause() error }:
+		return x.Cause(), false
+	}
+	return nil, false
+}
+
+// identifiable reports whether err is compared by identity. Only pointers
+// are: a cycle goes through a pointer, and comparing values may panic, such
+// as structs holding a map in an error field. The depth limit bounds the
+// rest.
+func identifiable(err error) bool {
+	return reflect.TypeOf(err).Kind() == reflect.Pointer
+}
+
+// visited reports whether the identifiable err is one of the visited errors.
+func visited(err error, scanned []error, indexed map[error]struct{}) bool {
+	for _, v := range scanned {
+		if v == err {
+			return true
+		}
+	}
+	if indexed == nil {
+		return false
+	}
+	_, ok := indexed[err]
+	return ok
+}
@@ -0,0 +1,182 @@
+// Copyright 2022 Zhiwen<zhiwen.t@outlook.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors_test
+
+import (
+	stderr "errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nextf/errors"
+)
+
+// loopError unwraps to the error set after it is created, possibly itself.
+type loopError struct {
+	code string
+	next error
+}
+
+func (e *loopError) Error() string { return e.code }
+func (e *loopError) Code() string  { return e.code }
+func (e *loopError) Unwrap() error { return e.next }
+
+// fields is an error that cannot be compared.
+type fields map[string]string
+
+func (f fields) Error() string { return fmt.Sprint(map[string]string(f)) }
+
+// opError is comparable, but holds an error that may not be.
+type opError struct {
+	op  string
+	err error
+}
+
+func (e opError) Error() string { return e.op + ": " + e.err.Error() }
+func (e opError) Unwrap() error { return e.err }
+
+type walked struct {
+	link  string
+	depth int
+}
+
+func walkAll(err error) []walked {
+	var links []walked
+	errors.Walk(err, func(link error, depth int) bool {
+		links = append(links, walked{link.Error(), depth})
+		return true
+	})
+	return links
+}
+
+func TestWalk(t *testing.T) {
+	a := errors.ErrCode("A", "a")
+	b := errors.WithErrCode(errors.New("b0"), "B", "b")
+	err := errors.WithErrCode(stderr.Join(a, nil, b), "J", "joined")
+	want := []walked{{"joined", 0}, {"a\nb", 1}, {"a", 2}, {"b", 2}, {"b0", 3}}
+	if got := walkAll(err); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expect %v, got %v", want, got)
+	}
+	if got := walkAll(nil); got != nil {
+		t.Errorf("Expect %v, got %v", nil, got)
+	}
+
+	var n int
+	errors.Walk(err, func(link error, depth int) bool {
+		n++
+		return depth < 1
+	})
+	if n != 2 {
+		t.Errorf("Expect Walk to stop after %d links, got %d", 2, n)
+	}
+
+	if !errors.Match(err, "B") || !errors.HasStackTrace(stderr.Join(a, errors.Trace(b))) {
+		t.Errorf("Expect Match and HasStackTrace to follow joined errors")
+	}
+	if path := errors.CodePath(err); !reflect.DeepEqual(path, []string{"J", "A", "B"}) {
+		t.Errorf("Expect %v, got %v", []string{"J", "A", "B"}, path)
+	}
+}
+
+func TestAll(t *testing.T) {
+	err := errors.Wrap(fmt.Errorf("read: %w", errors.ErrCode("IO_Read", "Read failed")), "API_Load", "Load failed")
+	var codes []string
+	for link := range errors.All(err) {
+		if code, ok := link.(interface{ Code() string }); ok {
+			codes = append(codes, code.Code())
+		}
+		if len(codes) == 2 {
+			break
+		}
+	}
+	if !reflect.DeepEqual(codes, []string{"API_Load", "IO_Read"}) {
+		t.Errorf("Expect %v, got %v", []string{"API_Load", "IO_Read"}, codes)
+	}
+}
+
+func TestWalkCycle(t *testing.T) {
+	self := &loopError{code: "SELF"}
+	self.next = self
+	a, b := &loopError{code: "A"}, &loopError{code: "B"}
+	a.next, b.next = b, a
+	many := make([]*loopError, 40)
+	for i := range many {
+		many[i] = &loopError{code: fmt.Sprintf("M%d", i)}
+		if i > 0 {
+			many[i-1].next = many[i]
+		}
+	}
+	many[len(many)-1].next = many[0]
+
+	for _, err := range []error{self, a, errors.Wrap(b, "W", "wrapped"), stderr.Join(self, self), many[0]} {
+		if errors.Match(err, "NONE") {
+			t.Errorf("%v: Expect no match", err)
+		}
+		if _, ok := errors.RootCode(err); !ok {
+			t.Errorf("%v: Expect a root code", err)
+		}
+		if errors.HasStackTrace(err) != errors.Match(err, "W") {
+			t.Errorf("%v: Expect a call stack only for the wrapped error", err)
+		}
+	}
+	if got := walkAll(a); !reflect.DeepEqual(got, []walked{{"A", 0}, {"B", 1}}) {
+		t.Errorf("Expect %v, got %v", []walked{{"A", 0}, {"B", 1}}, got)
+	}
+	if got := len(walkAll(many[0])); got != len(many) {
+		t.Errorf("Expect %d links, got %d", len(many), got)
+	}
+}
+
+func TestWalkUncomparable(t *testing.T) {
+	err := stderr.Join(opError{"validate", fields{"a": "x"}}, opError{"validate", fields{"b": "y"}})
+	for _, err := range []error{err, errors.Wrap(opError{"validate", fields{"a": "x"}}, "V", "invalid")} {
+		if errors.Match(err, "X") {
+			t.Errorf("%v: Expect no match", err)
+		}
+		if errors.HasStackTrace(err) != errors.Match(err, "V") {
+			t.Errorf("%v: Expect a call stack only for the wrapped error", err)
+		}
+		if got := fmt.Sprintf("%+v", err); !strings.Contains(got, "validate: map[") {
+			t.Errorf("Expect the causes to be printed, got %q", got)
+		}
+	}
+	if got := len(walkAll(err)); got != 5 {
+		t.Errorf("Expect 5 links, got %d", got)
+	}
+}
+
+func BenchmarkWalk(b *testing.B) {
+	var err error = errors.ErrCode("ROOT", "Level 0")
+	for i := 0; i < 5; i++ {
+		err = errors.WithErrCode(err, fmt.Sprintf("L%d", i+1), "Level")
+	}
+	b.Run("GetCode", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			errors.GetCode(err)
+		}
+	})
+	b.Run("MatchMissed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			errors.Match(err, "NONE")
+		}
+	})
+	joined := stderr.Join(err, errors.ErrCode("OTHER", "Other"))
+	b.Run("MatchMissedJoined", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			errors.Match(joined, "NONE")
+		}
+	})
+}